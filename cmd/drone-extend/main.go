@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oars-sigs/drone/handler/extendv1/repos/builds"
+)
+
+const usage = `Usage: drone-extend <command> [flags]
+
+Commands:
+  batch    trigger builds across many repositories
+
+The server address and token are read from the DRONE_SERVER
+and DRONE_TOKEN environment variables.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "batch":
+		os.Exit(batch(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// params is a repeatable key=value flag.
+type params map[string]string
+
+func (p params) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p params) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid parameter %q, expected key=value", value)
+	}
+	p[parts[0]] = parts[1]
+	return nil
+}
+
+func batch(args []string) int {
+	in := &builds.BatchInput{Params: params{}}
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	flags.StringVar(&in.Org, "org", "", "select repositories of the organization")
+	flags.StringVar(&in.Name, "name", "", "select repositories by name glob (e.g. svc-*)")
+	flags.StringVar(&in.Template, "template", "", "select repositories with pipelines derived from the template uuid")
+	flags.StringVar(&in.Branch, "branch", "", "branch to build, defaults to the repository default branch")
+	flags.Var(params(in.Params), "param", "build parameter key=value, repeatable")
+	flags.BoolVar(&in.DryRun, "dry-run", false, "report the selected repositories without triggering builds")
+	flags.IntVar(&in.Concurrency, "concurrency", 0, "maximum number of concurrent triggers")
+	flags.Parse(args)
+
+	server := strings.TrimSuffix(os.Getenv("DRONE_SERVER"), "/")
+	token := os.Getenv("DRONE_TOKEN")
+	if server == "" || token == "" {
+		fmt.Fprintln(os.Stderr, "DRONE_SERVER and DRONE_TOKEN are required")
+		return 2
+	}
+
+	body, _ := json.Marshal(in)
+	req, err := http.NewRequest("POST", server+"/extend/builds/batch", bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer res.Body.Close()
+	if res.StatusCode > 299 {
+		out, _ := ioutil.ReadAll(res.Body)
+		fmt.Fprintf(os.Stderr, "%s: %s\n", res.Status, out)
+		return 1
+	}
+
+	var results []*builds.BatchResult
+	if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSTATUS\tBUILD\tERROR")
+	for _, result := range results {
+		build := ""
+		if result.Number != 0 {
+			build = fmt.Sprint(result.Number)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Slug, result.Status, build, result.Error)
+		if result.Error != "" {
+			code = 1
+		}
+	}
+	w.Flush()
+	return code
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	spec "github.com/drone/drone/cmd/drone-server/config"
 	"github.com/drone/drone/core"
@@ -10,6 +12,9 @@ import (
 	"github.com/drone/drone/handler/web"
 	"github.com/drone/drone/metric"
 	"github.com/drone/drone/plugin/config"
+	"github.com/drone/drone/plugin/validator"
+	"github.com/drone/drone/plugin/webhook"
+	"github.com/drone/drone/service/hook/parser"
 	"github.com/drone/drone/store/shared/db"
 	"github.com/drone/go-login/login"
 	"github.com/drone/go-login/login/gitlab"
@@ -17,12 +22,25 @@ import (
 	"github.com/drone/go-scm/scm/transport/oauth2"
 	"github.com/go-chi/chi"
 	"github.com/google/wire"
+	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
 
 	"github.com/oars-sigs/drone/handler/extendv1"
+	auditsapi "github.com/oars-sigs/drone/handler/extendv1/audits"
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/leak"
 	"github.com/oars-sigs/drone/pkg/scm/driver/gitee"
+	"github.com/oars-sigs/drone/services/approval"
+	"github.com/oars-sigs/drone/services/audit"
 	"github.com/oars-sigs/drone/services/git"
+	"github.com/oars-sigs/drone/services/hook"
+	"github.com/oars-sigs/drone/services/netrc"
+	extwebhook "github.com/oars-sigs/drone/services/webhook"
+	"github.com/oars-sigs/drone/store/approvals"
+	"github.com/oars-sigs/drone/store/audits"
+	"github.com/oars-sigs/drone/store/credentials"
+	"github.com/oars-sigs/drone/store/deployments"
+	"github.com/oars-sigs/drone/store/overrides"
 	"github.com/oars-sigs/drone/store/pipelines"
 	extdb "github.com/oars-sigs/drone/store/shared/db"
 	"github.com/oars-sigs/drone/store/templates"
@@ -33,6 +51,17 @@ var extendSet = wire.NewSet(
 	pipelines.New,
 	extendv1.New,
 	git.New,
+	provideHookParser,
+	provideHookService,
+	provideNetrcService,
+	credentials.New,
+	overrides.New,
+	approvals.New,
+	deployments.New,
+	provideValidatePlugin,
+	provideLeakDetector,
+	provideAuditStore,
+	provideTrustedProxies,
 )
 
 // provideRouter is a Wire provider function that returns a
@@ -41,7 +70,18 @@ func provideRouter(api api.Server, apiext extendv1.Server, web web.Server, rpcv1
 	r := chi.NewRouter()
 	r.Mount("/healthz", healthz)
 	r.Mount("/metrics", metrics)
-	r.Mount("/api", api.Handler())
+	//@+++
+	// r.Mount("/api", api.Handler())
+	// the stage approval endpoint of the drone api is served
+	// by the extend api, which enforces the approval policy,
+	// and the build restart endpoint, which keeps the pipeline
+	// override of the build.
+	apiRouter := chi.NewRouter()
+	apiRouter.Post("/repos/{owner}/{name}/builds/{number}/approve/{stage}", apiext.ApproveHandler().ServeHTTP)
+	apiRouter.Post("/repos/{owner}/{name}/builds/{number}", apiext.RetryHandler().ServeHTTP)
+	apiRouter.Mount("/", api.Handler())
+	r.Mount("/api", apiRouter)
+	//@+++
 	r.Mount("/rpc/v2", rpcv2)
 	r.Mount("/rpc", rpcv1)
 	r.Mount("/", web.Handler())
@@ -52,11 +92,57 @@ func provideRouter(api api.Server, apiext extendv1.Server, web web.Server, rpcv1
 	return r
 }
 
+// provideHookParser is a Wire provider function that returns
+// a webhook parser that also accepts pull request comment
+// commands, configured from the environment.
+func provideHookParser(
+	client *scm.Client,
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	users core.UserStore,
+	perms core.PermStore,
+) core.HookParser {
+	return hook.NewCommentParser(
+		parser.New(client),
+		client,
+		repos,
+		builds,
+		users,
+		perms,
+		os.Getenv("DRONE_COMMENT_COMMAND"),
+	)
+}
+
+// provideHookService is a Wire provider function that returns a
+// hook service based on the environment configuration.
+func provideHookService(client *scm.Client, renewer core.Renewer, config spec.Config) core.HookService {
+	return hook.New(
+		client,
+		config.Proxy.Addr,
+		renewer,
+		os.Getenv("DRONE_COMMENT_COMMAND") != "",
+	)
+}
+
+// provideNetrcService is a Wire provider function that returns
+// a netrc service based on the environment configuration.
+func provideNetrcService(client *scm.Client, renewer core.Renewer, creds model.CredentialStore, config spec.Config) core.NetrcService {
+	return netrc.New(
+		client,
+		renewer,
+		creds,
+		config.Cloning.AlwaysAuth,
+		config.Cloning.Username,
+		config.Cloning.Password,
+	)
+}
+
 // provideConfigPlugin is a Wire provider function that returns
 // a yaml configuration plugin based on the environment
 // configuration.
-func provideConfigPlugin(client *scm.Client, contents core.FileService, pipeStore model.PipelineStore, conf spec.Config) core.ConfigService {
+func provideConfigPlugin(client *scm.Client, contents core.FileService, pipeStore model.PipelineStore, overrideStore model.OverrideStore, conf spec.Config) core.ConfigService {
 	return config.Combine(
+		overrideStore,
 		config.Memoize(
 			config.Global(
 				conf.Yaml.Endpoint,
@@ -69,13 +155,98 @@ func provideConfigPlugin(client *scm.Client, contents core.FileService, pipeStor
 	)
 }
 
+// provideValidatePlugin is a Wire provider function that
+// returns a yaml validation plugin based on the environment
+// configuration. Builds of pipelines with an approval policy
+// are blocked until approved.
+func provideValidatePlugin(pipeStore model.PipelineStore, conf spec.Config) core.ValidateService {
+	return validator.Combine(
+		validator.Remote(
+			conf.Validate.Endpoint,
+			conf.Validate.Secret,
+			conf.Validate.SkipVerify,
+			conf.Validate.Timeout,
+		),
+		// THIS FEATURE IS INTERNAL USE ONLY AND SHOULD
+		// NOT BE USED OR RELIED UPON IN PRODUCTION.
+		validator.Filter(
+			nil,
+			conf.Repository.Ignore,
+		),
+		approval.New(pipeStore),
+	)
+}
+
+// provideWebhookPlugin is a Wire provider function that returns
+// a webhook plugin based on the environment configuration. The
+// plugin also sends the extend api events.
+func provideWebhookPlugin(config spec.Config, system *core.System) model.WebhookSender {
+	return extwebhook.New(webhook.Config{
+		Events:   config.Webhook.Events,
+		Endpoint: config.Webhook.Endpoint,
+		Secret:   config.Webhook.Secret,
+		System:   system,
+	})
+}
+
+// provideAuditStore is a Wire provider function that returns an
+// audit log store. The audit log entries are also sent to the
+// webhook endpoints if DRONE_AUDIT_WEBHOOK is true.
+func provideAuditStore(db *db.DB, webhook model.WebhookSender) model.AuditStore {
+	export, _ := strconv.ParseBool(os.Getenv("DRONE_AUDIT_WEBHOOK"))
+	return audit.New(audits.New(db), webhook, export)
+}
+
+// provideTrustedProxies is a Wire provider function that returns
+// the reverse proxies trusted to set the forwarded headers of the
+// audit log source ip, configured from DRONE_AUDIT_TRUSTED_PROXIES.
+func provideTrustedProxies() (auditsapi.Proxies, error) {
+	return auditsapi.ParseProxies(os.Getenv("DRONE_AUDIT_TRUSTED_PROXIES"))
+}
+
+// provideLeakDetector is a Wire provider function that returns
+// a detector for secrets in pipeline and template content, based
+// on the environment configuration. The mode is off, warn or
+// reject, and defaults to warn.
+func provideLeakDetector() *leak.Detector {
+	return leak.New(os.Getenv("DRONE_LEAK_DETECTION"))
+}
+
 // provideDatabase is a Wire provider function that provides a
 // database connection, configured from the environment.
 func provideDatabase(config spec.Config) (*db.DB, error) {
-	return extdb.Connect(
+	pool := databaseConfig{}
+	if err := envconfig.Process("", &pool); err != nil {
+		return nil, err
+	}
+	conn, err := extdb.ConnectConfig(
 		config.Database.Driver,
 		config.Database.Datasource,
+		extdb.Config{
+			MaxOpenConns:      pool.MaxOpenConns,
+			MaxIdleConns:      pool.MaxIdleConns,
+			ConnMaxLifetime:   pool.ConnMaxLifetime,
+			ConnectTimeout:    pool.ConnectTimeout,
+			SqliteJournalMode: pool.SqliteJournalMode,
+			SqliteBusyTimeout: pool.SqliteBusyTimeout,
+		},
 	)
+	if err != nil {
+		return nil, err
+	}
+	extdb.RegisterMetrics(extdb.Pool(conn))
+	return conn, nil
+}
+
+// databaseConfig provides the database connection pool and
+// startup configuration.
+type databaseConfig struct {
+	MaxOpenConns      int           `envconfig:"DRONE_DATABASE_MAX_OPEN_CONNS"`
+	MaxIdleConns      int           `envconfig:"DRONE_DATABASE_MAX_IDLE_CONNS"`
+	ConnMaxLifetime   time.Duration `envconfig:"DRONE_DATABASE_CONN_MAX_LIFETIME"`
+	ConnectTimeout    time.Duration `envconfig:"DRONE_DATABASE_CONNECT_TIMEOUT" default:"2m"`
+	SqliteJournalMode string        `envconfig:"DRONE_DATABASE_SQLITE_JOURNAL_MODE" default:"WAL"`
+	SqliteBusyTimeout time.Duration `envconfig:"DRONE_DATABASE_SQLITE_BUSY_TIMEOUT" default:"5s"`
 }
 
 // provideBitbucketClient is a Wire provider function that
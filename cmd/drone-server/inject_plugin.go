@@ -21,9 +21,8 @@ import (
 	"github.com/drone/drone/plugin/converter"
 	"github.com/drone/drone/plugin/registry"
 	"github.com/drone/drone/plugin/secret"
-	"github.com/drone/drone/plugin/validator"
-	"github.com/drone/drone/plugin/webhook"
 	"github.com/drone/go-scm/scm"
+	"github.com/oars-sigs/drone/model"
 
 	"github.com/google/wire"
 )
@@ -35,8 +34,10 @@ var pluginSet = wire.NewSet(
 	provideConvertPlugin,
 	provideRegistryPlugin,
 	provideSecretPlugin,
-	provideValidatePlugin,
 	provideWebhookPlugin,
+	//@+++
+	wire.Bind(new(core.WebhookSender), new(model.WebhookSender)),
+	//@+++
 )
 
 // provideAdmissionPlugin is a Wire provider function that
@@ -125,33 +126,13 @@ func provideSecretPlugin(config spec.Config) core.SecretService {
 	)
 }
 
-// provideValidatePlugin is a Wire provider function that
-// returns a yaml validation plugin based on the environment
-// configuration.
-func provideValidatePlugin(conf spec.Config) core.ValidateService {
-	return validator.Combine(
-		validator.Remote(
-			conf.Validate.Endpoint,
-			conf.Validate.Secret,
-			conf.Validate.SkipVerify,
-			conf.Validate.Timeout,
-		),
-		// THIS FEATURE IS INTERNAL USE ONLY AND SHOULD
-		// NOT BE USED OR RELIED UPON IN PRODUCTION.
-		validator.Filter(
-			nil,
-			conf.Repository.Ignore,
-		),
-	)
-}
-
 // provideWebhookPlugin is a Wire provider function that returns
 // a webhook plugin based on the environment configuration.
-func provideWebhookPlugin(config spec.Config, system *core.System) core.WebhookSender {
-	return webhook.New(webhook.Config{
-		Events:   config.Webhook.Events,
-		Endpoint: config.Webhook.Endpoint,
-		Secret:   config.Webhook.Secret,
-		System:   system,
-	})
-}
+// func provideWebhookPlugin(config spec.Config, system *core.System) core.WebhookSender {
+// 	return webhook.New(webhook.Config{
+// 		Events:   config.Webhook.Events,
+// 		Endpoint: config.Webhook.Endpoint,
+// 		Secret:   config.Webhook.Secret,
+// 		System:   system,
+// 	})
+// }
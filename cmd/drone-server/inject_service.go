@@ -27,10 +27,7 @@ import (
 	"github.com/drone/drone/service/commit"
 	contents "github.com/drone/drone/service/content"
 	"github.com/drone/drone/service/content/cache"
-	"github.com/drone/drone/service/hook"
-	"github.com/drone/drone/service/hook/parser"
 	"github.com/drone/drone/service/linker"
-	"github.com/drone/drone/service/netrc"
 	orgs "github.com/drone/drone/service/org"
 	"github.com/drone/drone/service/repo"
 	"github.com/drone/drone/service/status"
@@ -54,7 +51,6 @@ var serviceSet = wire.NewSet(
 	cron.New,
 	livelog.New,
 	linker.New,
-	parser.New,
 	pubsub.New,
 	token.Renewer,
 	transfer.New,
@@ -64,8 +60,6 @@ var serviceSet = wire.NewSet(
 	provideRepositoryService,
 	provideContentService,
 	provideDatadog,
-	provideHookService,
-	provideNetrcService,
 	provideOrgService,
 	provideReaper,
 	provideSession,
@@ -82,24 +76,6 @@ func provideContentService(client *scm.Client, renewer core.Renewer) core.FileSe
 	)
 }
 
-// provideHookService is a Wire provider function that returns a
-// hook service based on the environment configuration.
-func provideHookService(client *scm.Client, renewer core.Renewer, config config.Config) core.HookService {
-	return hook.New(client, config.Proxy.Addr, renewer)
-}
-
-// provideNetrcService is a Wire provider function that returns
-// a netrc service based on the environment configuration.
-func provideNetrcService(client *scm.Client, renewer core.Renewer, config config.Config) core.NetrcService {
-	return netrc.New(
-		client,
-		renewer,
-		config.Cloning.AlwaysAuth,
-		config.Cloning.Username,
-		config.Cloning.Password,
-	)
-}
-
 // provideOrgService is a Wire provider function that
 // returns an organization service wrapped with a simple cache.
 func provideOrgService(client *scm.Client, renewer core.Renewer) core.OrganizationService {
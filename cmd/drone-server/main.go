@@ -18,6 +18,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 
 	"github.com/drone/drone/cmd/drone-server/bootstrap"
 	"github.com/drone/drone/cmd/drone-server/config"
@@ -51,6 +52,27 @@ func main() {
 	}
 
 	initLogging(config)
+
+	// the subcommands manage the database and exit without
+	// starting the server.
+	switch flag.Arg(0) {
+	case "migrate":
+		err := runMigrate(config, flag.Args()[1:])
+		if err == errMigrateUsage {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		if err != nil {
+			logrus.WithError(err).Fatalln("main: cannot migrate the database")
+		}
+		return
+	case "rotate-secret":
+		err := runRotate(config)
+		if err != nil {
+			logrus.WithError(err).Fatalln("main: cannot re-encrypt the database content")
+		}
+		return
+	}
 	ctx := signal.WithContext(
 		context.Background(),
 	)
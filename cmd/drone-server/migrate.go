@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oars-sigs/drone/store/shared/migrate"
+	"github.com/oars-sigs/drone/store/shared/migrate/mysql"
+	"github.com/oars-sigs/drone/store/shared/migrate/postgres"
+	"github.com/oars-sigs/drone/store/shared/migrate/sqlite"
+
+	"github.com/drone/drone/cmd/drone-server/config"
+	dronemysql "github.com/drone/drone/store/shared/migrate/mysql"
+	dronepostgres "github.com/drone/drone/store/shared/migrate/postgres"
+	dronesqlite "github.com/drone/drone/store/shared/migrate/sqlite"
+)
+
+const migrateUsage = `usage: drone-server migrate <command>
+
+commands:
+  status    list the migrations and their status
+  up        apply the pending migrations, importing the migrations
+            recorded by earlier versions
+  down N    revert the last N applied migrations, stopping at a
+            migration that deletes data and cannot be reverted
+  verify    verify the checksums of the applied migrations
+
+status and verify do not write to the database. On MySQL, down
+is not atomic: DDL statements are committed implicitly, so a
+failed revert may leave the schema changed while the migration
+is still recorded as applied.`
+
+// errMigrateUsage is returned when the migrate subcommand is
+// invoked with invalid arguments.
+var errMigrateUsage = errors.New("invalid migrate command")
+
+// runMigrate runs the migrate subcommand, which manages the
+// tpipe schema migrations without starting the server.
+func runMigrate(config config.Config, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	db, err := sql.Open(config.Database.Driver, config.Database.Datasource)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var m *migrate.Migrator
+	var setup func(*sql.DB) error
+	switch config.Database.Driver {
+	case "mysql":
+		m = mysql.New(db)
+		setup = dronemysql.Migrate
+	case "postgres":
+		m = postgres.New(db)
+		setup = dronepostgres.Migrate
+	default:
+		m = sqlite.New(db)
+		setup = dronesqlite.Migrate
+	}
+
+	switch args[0] {
+	case "status":
+		list, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATUS\tAPPLIED\tCHECKSUM")
+		for _, status := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status.Name, migrateStatus(status), migrateApplied(status), status.Checksum)
+		}
+		return w.Flush()
+	case "up":
+		// the drone schema is migrated first, since the tpipe
+		// migrations backfill from the drone tables.
+		if err := setup(db); err != nil {
+			return err
+		}
+		n, err := m.Up()
+		fmt.Printf("applied %d migrations\n", n)
+		return err
+	case "down":
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+		count, err := strconv.Atoi(args[1])
+		if err != nil || count < 1 {
+			return fmt.Errorf("invalid number of migrations: %s", args[1])
+		}
+		n, err := m.Down(count)
+		fmt.Printf("reverted %d migrations\n", n)
+		return err
+	case "verify":
+		if err := m.Verify(); err != nil {
+			return err
+		}
+		fmt.Println("migrations verified")
+		return nil
+	default:
+		return errMigrateUsage
+	}
+}
+
+// helper function returns the status of the migration.
+func migrateStatus(status *migrate.Status) string {
+	switch {
+	case status.Pending:
+		return "pending"
+	case status.Unknown:
+		return "unknown"
+	case status.Modified:
+		return "modified"
+	default:
+		return "applied"
+	}
+}
+
+// helper function returns the applied time of the migration.
+// Migrations imported from earlier versions have no applied
+// time.
+func migrateApplied(status *migrate.Status) string {
+	if status.Applied == 0 {
+		return "-"
+	}
+	return time.Unix(status.Applied, 0).UTC().Format(time.RFC3339)
+}
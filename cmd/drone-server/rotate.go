@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	extdb "github.com/oars-sigs/drone/store/shared/db"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
+
+	"github.com/drone/drone/cmd/drone-server/config"
+	dencrypt "github.com/drone/drone/store/shared/encrypt"
+)
+
+// encrypted columns, by table and primary key.
+var encryptedColumns = []struct {
+	table  string
+	key    string
+	column string
+}{
+	{"tpipe_pipelines", "pipeline_uuid", "pipeline_content"},
+	{"tpipe_revisions", "revision_id", "revision_content"},
+	{"tpipe_templates", "template_uuid", "template_content"},
+	{"tpipe_overrides", "override_uuid", "override_content"},
+	{"tpipe_credentials", "credential_slug", "credential_password"},
+}
+
+// runRotate runs the rotate-secret subcommand, which decrypts
+// the pipeline, override and template content and the clone
+// credential passwords with the previous database secret
+// (DRONE_DATABASE_SECRET_PREVIOUS) and encrypts them with the
+// current database secret. Either secret may be empty to
+// encrypt plaintext content or decrypt all content. The server
+// should be stopped while the content is re-encrypted.
+func runRotate(config config.Config) error {
+	prev, err := dencrypt.New(os.Getenv("DRONE_DATABASE_SECRET_PREVIOUS"))
+	if err != nil {
+		return err
+	}
+	next, err := dencrypt.New(config.Database.Secret)
+	if err != nil {
+		return err
+	}
+	conn, err := extdb.Connect(config.Database.Driver, config.Database.Datasource)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, c := range encryptedColumns {
+		n, err := encrypt.Rotate(conn, c.table, c.key, c.column, prev, next)
+		fmt.Printf("%s: re-encrypted %d rows\n", c.table, n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
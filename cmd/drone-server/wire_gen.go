@@ -14,7 +14,6 @@ import (
 	"github.com/drone/drone/pubsub"
 	"github.com/drone/drone/service/canceler"
 	"github.com/drone/drone/service/commit"
-	"github.com/drone/drone/service/hook/parser"
 	"github.com/drone/drone/service/license"
 	"github.com/drone/drone/service/linker"
 	"github.com/drone/drone/service/token"
@@ -29,6 +28,10 @@ import (
 	cron2 "github.com/drone/drone/trigger/cron"
 	"github.com/oars-sigs/drone/handler/extendv1"
 	"github.com/oars-sigs/drone/services/git"
+	"github.com/oars-sigs/drone/store/approvals"
+	"github.com/oars-sigs/drone/store/credentials"
+	"github.com/oars-sigs/drone/store/deployments"
+	"github.com/oars-sigs/drone/store/overrides"
 	"github.com/oars-sigs/drone/store/pipelines"
 	"github.com/oars-sigs/drone/store/templates"
 )
@@ -63,10 +66,15 @@ func InitializeApplication(config2 config.Config) (application, error) {
 	webhookSender := provideWebhookPlugin(config2, system)
 	coreCanceler := canceler.New(buildStore, corePubsub, repositoryStore, scheduler, stageStore, statusService, stepStore, userStore, webhookSender)
 	fileService := provideContentService(client, renewer)
-	pipelineStore := pipelines.New(db)
-	configService := provideConfigPlugin(client, fileService, pipelineStore, config2)
+	v, err := provideEncrypter(config2)
+	if err != nil {
+		return application{}, err
+	}
+	pipelineStore := pipelines.New(db, v)
+	overrideStore := overrides.New(db, v)
+	configService := provideConfigPlugin(client, fileService, pipelineStore, overrideStore, config2)
 	convertService := provideConvertPlugin(client, config2)
-	validateService := provideValidatePlugin(config2)
+	validateService := provideValidatePlugin(pipelineStore, config2)
 	triggerer := trigger.New(coreCanceler, configService, convertService, commitService, statusService, buildStore, scheduler, repositoryStore, userStore, validateService, webhookSender)
 	cronScheduler := cron2.New(commitService, cronStore, repositoryStore, userStore, triggerer)
 	reaper := provideReaper(repositoryStore, buildStore, stageStore, coreCanceler, config2)
@@ -74,13 +82,10 @@ func InitializeApplication(config2 config.Config) (application, error) {
 	datadog := provideDatadog(userStore, repositoryStore, buildStore, system, coreLicense, config2)
 	logStore := provideLogStore(db, config2)
 	logStream := livelog.New()
-	netrcService := provideNetrcService(client, renewer, config2)
-	encrypter, err := provideEncrypter(config2)
-	if err != nil {
-		return application{}, err
-	}
-	secretStore := secret.New(db, encrypter)
-	globalSecretStore := global.New(db, encrypter)
+	credentialStore := credentials.New(db, v)
+	netrcService := provideNetrcService(client, renewer, credentialStore, config2)
+	secretStore := secret.New(db, v)
+	globalSecretStore := global.New(db, v)
 	buildManager := manager.New(buildStore, configService, convertService, corePubsub, logStore, logStream, netrcService, repositoryStore, scheduler, secretStore, globalSecretStore, statusService, stageStore, stepStore, system, userStore, webhookSender)
 	secretService := provideSecretPlugin(config2)
 	registryService := provideRegistryPlugin(config2)
@@ -99,11 +104,19 @@ func InitializeApplication(config2 config.Config) (application, error) {
 	transferer := transfer.New(repositoryStore, permStore)
 	userService := user.New(client, renewer)
 	server := api.New(buildStore, commitService, cronStore, corePubsub, globalSecretStore, hookService, logStore, coreLicense, licenseService, organizationService, permStore, repositoryStore, repositoryService, scheduler, secretStore, stageStore, stepStore, statusService, session, logStream, syncer, system, transferer, triggerer, userStore, userService, webhookSender)
-	templateStore := templates.New(db)
+	templateStore := templates.New(db, v)
 	gitService := git.New(client, renewer)
-	extendv1Server := extendv1.New(buildStore, commitService, cronStore, corePubsub, globalSecretStore, hookService, logStore, coreLicense, licenseService, permStore, repositoryStore, repositoryService, scheduler, secretStore, stageStore, stepStore, statusService, session, logStream, syncer, system, triggerer, userStore, webhookSender, templateStore, pipelineStore, gitService)
+	approvalStore := approvals.New(db)
+	detector := provideLeakDetector()
+	auditStore := provideAuditStore(db, webhookSender)
+	deploymentStore := deployments.New(db)
+	proxies, err := provideTrustedProxies()
+	if err != nil {
+		return application{}, err
+	}
+	extendv1Server := extendv1.New(buildStore, commitService, cronStore, corePubsub, globalSecretStore, hookService, logStore, coreLicense, licenseService, permStore, repositoryStore, repositoryService, scheduler, secretStore, stageStore, stepStore, statusService, session, logStream, syncer, system, triggerer, userStore, webhookSender, templateStore, pipelineStore, gitService, credentialStore, overrideStore, approvalStore, detector, auditStore, deploymentStore, proxies)
 	admissionService := provideAdmissionPlugin(client, organizationService, userService, config2)
-	hookParser := parser.New(client)
+	hookParser := provideHookParser(client, repositoryStore, buildStore, userStore, permStore)
 	coreLinker := linker.New(client)
 	middleware := provideLogin(config2)
 	options := provideServerOptions(config2)
@@ -8,9 +8,11 @@ replace (
 )
 
 require (
+	github.com/99designs/httpsignatures-go v0.0.0-20170731043157-88528bf4ca7e
 	github.com/buildkite/yaml v2.1.0+incompatible // indirect
 	github.com/drone/drone v1.9.2
 	github.com/drone/drone-runtime v1.1.1-0.20200623162453-61e33e2cab5d
+	github.com/drone/drone-yaml v1.2.4-0.20200326192514-6f4d6dfb39e4
 	github.com/drone/go-login v1.0.4-0.20190311170324-2a4df4f242a2
 	github.com/drone/go-scm v1.7.2-0.20201028160627-427b8a85897c
 	github.com/drone/signal v1.0.0
@@ -21,8 +23,11 @@ require (
 	github.com/google/wire v0.4.0
 	github.com/jmoiron/sqlx v0.0.0-20180614180643-0dae4fefe7c0
 	github.com/joho/godotenv v1.3.0
+	github.com/kelseyhightower/envconfig v1.3.0
 	github.com/lib/pq v1.8.0
 	github.com/mattn/go-sqlite3 v1.14.5
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/prometheus/client_golang v0.9.2
 	github.com/sirupsen/logrus v1.7.0
 	github.com/unrolled/secure v1.0.8
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
@@ -3,11 +3,14 @@ package extendv1
 import (
 	"net/http"
 
+	"github.com/oars-sigs/drone/handler/extendv1/audits"
 	"github.com/oars-sigs/drone/handler/extendv1/repos/builds"
+	"github.com/oars-sigs/drone/handler/extendv1/repos/credentials"
 	"github.com/oars-sigs/drone/handler/extendv1/repos/pipelines"
 	"github.com/oars-sigs/drone/handler/extendv1/repos/ref"
 	"github.com/oars-sigs/drone/handler/extendv1/templates"
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/leak"
 
 	"github.com/drone/drone/core"
 	"github.com/drone/drone/handler/api/acl"
@@ -52,10 +55,17 @@ func New(
 	system *core.System,
 	triggerer core.Triggerer,
 	users core.UserStore,
-	webhook core.WebhookSender,
+	webhook model.WebhookSender,
 	tmpls model.TemplateStore,
 	pipelineStore model.PipelineStore,
 	gits model.GitService,
+	creds model.CredentialStore,
+	overrides model.OverrideStore,
+	approvals model.ApprovalStore,
+	leaks *leak.Detector,
+	auditStore model.AuditStore,
+	deployments model.DeploymentStore,
+	proxies audits.Proxies,
 ) Server {
 	return Server{
 		Builds:    builds,
@@ -86,6 +96,13 @@ func New(
 		Tmpls:         tmpls,
 		PipelineStore: pipelineStore,
 		gits:          gits,
+		Creds:         creds,
+		Overrides:     overrides,
+		Approvals:     approvals,
+		Leaks:         leaks,
+		Audits:        auditStore,
+		Deployments:   deployments,
+		Proxies:       proxies,
 	}
 }
 
@@ -114,11 +131,18 @@ type Server struct {
 	System    *core.System
 	Triggerer core.Triggerer
 	Users     core.UserStore
-	Webhook   core.WebhookSender
+	Webhook   model.WebhookSender
 
 	Tmpls         model.TemplateStore
 	PipelineStore model.PipelineStore
 	gits          model.GitService
+	Creds         model.CredentialStore
+	Overrides     model.OverrideStore
+	Approvals     model.ApprovalStore
+	Leaks         *leak.Detector
+	Audits        model.AuditStore
+	Deployments   model.DeploymentStore
+	Proxies       audits.Proxies
 }
 
 // Handler returns an http.Handler
@@ -132,29 +156,100 @@ func (s Server) Handler() http.Handler {
 
 	cors := cors.New(corsOpts)
 	r.Use(cors.Handler)
+	r.Use(audits.Record(s.Audits, s.Proxies))
+
+	r.With(acl.AuthorizeAdmin).Get("/audits", audits.HandleList(s.Audits))
 
 	r.Route("/templates", func(r chi.Router) {
 		r.Get("/", templates.HandleGetTemp(s.Tmpls))
-		r.Post("/", templates.HandleCreateTemp(s.Tmpls))
+		r.Post("/", templates.HandleCreateTemp(s.Tmpls, s.Leaks, s.Webhook))
 		r.Route("/{uuid}", func(r chi.Router) {
 			r.Get("/", templates.HandleFindTemp(s.Tmpls))
-			r.Put("/", templates.HandlePutTemp(s.Tmpls))
-			r.Delete("/", templates.HandleDeleteTemp(s.Tmpls))
+			r.Put("/", templates.HandlePutTemp(s.Tmpls, s.Leaks, s.Webhook))
+			r.Delete("/", templates.HandleDeleteTemp(s.Tmpls, s.Webhook))
 		})
 
 	})
 
+	r.Post("/builds/batch", builds.HandleBatch(s.Users, s.Repos, s.Perms, s.Commits, s.PipelineStore, s.Triggerer))
+
 	r.Route("/{owner}/{name}", func(r chi.Router) {
 		r.Get("/branches", ref.HandleFindBranches(s.Repos, s.gits))
 		r.Get("/tags", ref.HandleFindTags(s.Repos, s.gits))
 		r.Route("/builds", func(r chi.Router) {
-			r.Post("/", builds.HandleCreate(s.Users, s.Repos, s.Commits, s.Triggerer))
+			r.Get("/{number}/approvals", builds.HandleListApprovals(s.Repos, s.Builds, s.PipelineStore, s.Approvals))
+			r.Post("/{number}/approve", builds.HandleApprove(s.Repos, s.Builds, s.Stages, s.Perms, s.PipelineStore, s.Approvals, s.Scheduler))
+			r.Group(func(r chi.Router) {
+				r.Use(acl.InjectRepository(s.Repoz, s.Repos, s.Perms))
+				r.Use(acl.CheckWriteAccess())
+				r.Post("/", builds.HandleCreate(s.Users, s.Repos, s.Commits, s.gits, s.PipelineStore, s.Overrides, s.Triggerer))
+				r.Post("/{number}/promote", builds.HandlePromote(s.Repos, s.Builds, s.PipelineStore, s.Overrides, s.Triggerer))
+				r.Post("/rollback", builds.HandleRollback(s.Repos, s.Deployments, s.PipelineStore, s.Overrides, s.Triggerer))
+			})
 		})
+		r.With(
+			acl.InjectRepository(s.Repoz, s.Repos, s.Perms),
+			acl.CheckReadAccess(),
+		).Get("/deployments", builds.HandleDeployments(s.Repos, s.Deployments))
 		r.Route("/pipelines", func(r chi.Router) {
 			r.Get("/", pipelines.HandleFindPipelines(s.Repos, s.PipelineStore))
-			r.Put("/", pipelines.HandlePutPipeline(s.Repos, s.PipelineStore))
+			r.With(
+				acl.InjectRepository(s.Repoz, s.Repos, s.Perms),
+				acl.CheckReadAccess(),
+			).Get("/events", pipelines.HandleEvents(s.Repos, s.Events))
+			r.With(
+				acl.InjectRepository(s.Repoz, s.Repos, s.Perms),
+				acl.CheckReadAccess(),
+			).Get("/diff", pipelines.HandleDiff(s.Repos, s.PipelineStore, s.gits))
+			r.Get("/params", pipelines.HandleFindParams(s.Repos, s.PipelineStore))
+			r.Get("/approval", pipelines.HandleFindApproval(s.Repos, s.PipelineStore))
+			r.With(
+				acl.InjectRepository(s.Repoz, s.Repos, s.Perms),
+				acl.CheckAdminAccess(),
+			).Put("/approval", pipelines.HandlePutApproval(s.Repos, s.PipelineStore))
+			r.Group(func(r chi.Router) {
+				r.Use(acl.InjectRepository(s.Repoz, s.Repos, s.Perms))
+				r.Use(acl.CheckWriteAccess())
+				r.Put("/", pipelines.HandlePutPipeline(s.Repos, s.PipelineStore, s.Leaks, s.Webhook, s.Events))
+				r.Delete("/", pipelines.HandleDeletePipeline(s.Repos, s.PipelineStore, s.Webhook, s.Events))
+				r.Post("/commit", pipelines.HandleCommitPipeline(s.Repos, s.PipelineStore, s.gits))
+				r.Put("/params", pipelines.HandlePutParams(s.Repos, s.PipelineStore))
+			})
+		})
+		r.Route("/credentials", func(r chi.Router) {
+			r.Use(acl.InjectRepository(s.Repoz, s.Repos, s.Perms))
+			r.Use(acl.CheckAdminAccess())
+			r.Get("/", credentials.HandleFind(s.Creds))
+			r.Put("/", credentials.HandlePut(s.Creds, s.Secrets, s.gits))
+			r.Delete("/", credentials.HandleDelete(s.Creds, s.Secrets, s.gits))
 		})
 
 	})
 	return r
 }
+
+// ApproveHandler returns an http.Handler that approves blocked
+// builds with the approval policy of the pipeline. It serves
+// the stage approval endpoint of the drone api, which would
+// otherwise bypass the approval policy.
+func (s Server) ApproveHandler() http.Handler {
+	return chi.Chain(
+		auth.HandleAuthentication(s.Session),
+		acl.AuthorizeUser,
+		audits.Record(s.Audits, s.Proxies),
+	).Handler(builds.HandleApprove(s.Repos, s.Builds, s.Stages, s.Perms, s.PipelineStore, s.Approvals, s.Scheduler))
+}
+
+// RetryHandler returns an http.Handler that restarts builds with
+// the pipeline override of the restarted build. It serves the
+// build restart endpoint of the drone api, which would otherwise
+// run the stored pipeline.
+func (s Server) RetryHandler() http.Handler {
+	return chi.Chain(
+		auth.HandleAuthentication(s.Session),
+		acl.AuthorizeUser,
+		acl.InjectRepository(s.Repoz, s.Repos, s.Perms),
+		acl.CheckWriteAccess(),
+		audits.Record(s.Audits, s.Proxies),
+	).Handler(builds.HandleRetry(s.Repos, s.Builds, s.Overrides, s.Triggerer))
+}
@@ -0,0 +1,55 @@
+package audits
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/handler/api/render"
+)
+
+// HandleList returns an http.HandlerFunc that processes http
+// requests to list the audit log, filtered by the actor,
+// action, target prefix and time range query parameters.
+func HandleList(audits model.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := model.AuditFilter{
+			Actor:  r.FormValue("actor"),
+			Action: r.FormValue("action"),
+			Target: r.FormValue("target"),
+		}
+		for name, dest := range map[string]*int64{
+			"since": &filter.Since,
+			"until": &filter.Until,
+		} {
+			if v := r.FormValue(name); v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					render.BadRequestf(w, "Invalid %s parameter", name)
+					return
+				}
+				*dest = n
+			}
+		}
+		for name, dest := range map[string]*int{
+			"limit":  &filter.Limit,
+			"offset": &filter.Offset,
+		} {
+			if v := r.FormValue(name); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					render.BadRequestf(w, "Invalid %s parameter", name)
+					return
+				}
+				*dest = n
+			}
+		}
+		out, err := audits.List(r.Context(), filter)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, out, 200)
+	}
+}
@@ -0,0 +1,50 @@
+package audits
+
+import (
+	"net"
+	"strings"
+)
+
+// Proxies is the list of trusted reverse proxy networks. The
+// forwarded headers are only read from requests sent by a
+// trusted proxy, since any client can set them.
+type Proxies []*net.IPNet
+
+// ParseProxies parses a comma separated list of ip addresses
+// and cidr networks.
+func ParseProxies(s string) (Proxies, error) {
+	var proxies Proxies
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if strings.Contains(item, ":") {
+				item = item + "/128"
+			} else {
+				item = item + "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains returns true if the address is a trusted proxy.
+func (p Proxies) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
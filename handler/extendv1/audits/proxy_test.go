@@ -0,0 +1,35 @@
+package audits
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSourceIP(t *testing.T) {
+	proxies, err := ParseProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remote    string
+		forwarded string
+		want      string
+	}{
+		// the forwarded headers of untrusted clients are ignored.
+		{"203.0.113.7:51234", "198.51.100.1", "203.0.113.7"},
+		{"192.168.1.1:51234", "198.51.100.1", "198.51.100.1"},
+		// addresses prepended by the client are ignored.
+		{"10.1.2.3:51234", "198.51.100.1, 203.0.113.7, 10.4.5.6", "203.0.113.7"},
+		{"10.1.2.3:51234", "", "10.1.2.3"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("PUT", "/", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if got := sourceIP(r, proxies); got != test.want {
+			t.Errorf("Want source ip %s for %s, got %s", test.want, test.remote, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package audits
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// maximum size of the request body of mutating requests.
+const maxBodySize = 10000000
+
+type auditKey struct{}
+
+// Record returns an http.Handler middleware that records the
+// mutating requests to the audit log. The after digest defaults
+// to the digest of the request body. Handlers that know the
+// target content record the digests with SetBefore and SetAfter.
+// The source ip is read from the forwarded headers only if the
+// request is sent by one of the trusted proxies.
+func Record(audits model.AuditStore, proxies Proxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "GET", "HEAD", "OPTIONS":
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := ioutil.ReadAll(
+				http.MaxBytesReader(w, r.Body, maxBodySize),
+			)
+			if err != nil {
+				render.ErrorCode(w, err, http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			audit := &model.Audit{
+				Target:   target(r),
+				After:    Digest(string(body)),
+				SourceIP: sourceIP(r, proxies),
+				Created:  time.Now().Unix(),
+			}
+			if user, ok := request.UserFrom(r.Context()); ok {
+				audit.Actor = user.Login
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(
+				context.WithValue(r.Context(), auditKey{}, audit),
+			))
+
+			// the route pattern is only known once the request
+			// is routed.
+			audit.Action = r.Method + " " + strings.TrimSuffix(chi.RouteContext(r.Context()).RoutePattern(), "/")
+			audit.Status = ww.Status()
+			if audit.Status == 0 {
+				audit.Status = http.StatusOK
+			}
+			// the entry is recorded even if the client has
+			// disconnected and the request context is canceled.
+			if err := audits.Create(context.Background(), audit); err != nil {
+				logrus.WithError(err).
+					WithField("action", audit.Action).
+					WithField("target", audit.Target).
+					Errorln("audit: cannot record the request")
+			}
+		})
+	}
+}
+
+// SetBefore records the digest of the target content before
+// the change.
+func SetBefore(ctx context.Context, content string) {
+	if audit, ok := ctx.Value(auditKey{}).(*model.Audit); ok {
+		audit.Before = Digest(content)
+	}
+}
+
+// SetAfter records the digest of the target content after the
+// change. The content of a deleted target is empty.
+func SetAfter(ctx context.Context, content string) {
+	if audit, ok := ctx.Value(auditKey{}).(*model.Audit); ok {
+		audit.After = Digest(content)
+	}
+}
+
+// Digest returns the sha256 digest of the content, or an empty
+// string if the content is empty.
+func Digest(content string) string {
+	if content == "" {
+		return ""
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+}
+
+// helper function returns the target of the request. The
+// access token is removed from the query, since drone accepts
+// it as a query parameter and the target is stored and
+// exported in plain text.
+func target(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del("access_token")
+	if len(query) == 0 {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// helper function returns the source ip of the request. If the
+// request is sent by a trusted proxy, the client is the last
+// forwarded address that is not a trusted proxy, since each
+// proxy appends the address it received the request from.
+func sourceIP(r *http.Request, proxies Proxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !proxies.Contains(host) {
+		return host
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		addrs := strings.Split(forwarded, ",")
+		for i := len(addrs) - 1; i > 0; i-- {
+			if addr := strings.TrimSpace(addrs[i]); !proxies.Contains(addr) {
+				return addr
+			}
+		}
+		return strings.TrimSpace(addrs[0])
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return host
+}
@@ -0,0 +1,96 @@
+package audits
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/services/audit"
+
+	"github.com/drone/drone/core"
+	"github.com/go-chi/chi"
+)
+
+type auditStore struct {
+	model.AuditStore
+	created []*model.Audit
+}
+
+func (s *auditStore) Create(ctx context.Context, audit *model.Audit) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.created = append(s.created, audit)
+	return nil
+}
+
+type webhookSender struct {
+	payloads chan []byte
+}
+
+func (s *webhookSender) Send(context.Context, *core.WebhookData) error {
+	return nil
+}
+
+func (s *webhookSender) SendEvent(ctx context.Context, event, action string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	s.payloads <- payload
+	return err
+}
+
+func TestRecord(t *testing.T) {
+	store := new(auditStore)
+	sender := &webhookSender{payloads: make(chan []byte, 1)}
+
+	router := chi.NewRouter()
+	router.Use(Record(audit.New(store, sender, true), nil))
+	router.Put("/repos/{owner}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/repos/octocat/hello-world?access_token=VA.197XXbZablx0RPQ8&ref=master", nil)
+	router.ServeHTTP(w, r)
+
+	if len(store.created) != 1 {
+		t.Fatalf("Want 1 audit log entry, got %d", len(store.created))
+	}
+	if got, want := store.created[0].Target, "/repos/octocat/hello-world?ref=master"; got != want {
+		t.Errorf("Want target %s, got %s", want, got)
+	}
+	select {
+	case payload := <-sender.payloads:
+		if strings.Contains(string(payload), "VA.197XXbZablx0RPQ8") {
+			t.Errorf("Want access token removed from the webhook payload, got %s", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Want audit log entry sent to the webhook")
+	}
+}
+
+func TestRecord_Canceled(t *testing.T) {
+	store := new(auditStore)
+
+	router := chi.NewRouter()
+	router.Use(Record(store, nil))
+	router.Delete("/repos/{owner}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// the client disconnects before the entry is recorded.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/repos/octocat/hello-world", nil)
+	router.ServeHTTP(w, r.WithContext(ctx))
+
+	if len(store.created) != 1 {
+		t.Errorf("Want 1 audit log entry, got %d", len(store.created))
+	}
+}
@@ -0,0 +1,239 @@
+package builds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/drone/store/shared/db"
+	"github.com/go-chi/chi"
+)
+
+var errApproverRequired = errors.New("You are not allowed to approve the build")
+
+// approvalStatus reports the approvals of a blocked build.
+type approvalStatus struct {
+	Approvals []*model.Approval `json:"approvals"`
+	Required  int               `json:"required"`
+	Approved  bool              `json:"approved"`
+}
+
+// HandleListApprovals returns an http.HandlerFunc that writes
+// the json-encoded approvals of the build to the response body.
+func HandleListApprovals(
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	pipelineStore model.PipelineStore,
+	approvals model.ApprovalStore,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		repo, build, ok := findBuild(w, r, repos, builds)
+		if !ok {
+			return
+		}
+		policy, err := findPolicy(ctx, pipelineStore, repo, build)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		list, err := approvals.List(ctx, build.ID)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, &approvalStatus{
+			Approvals: list,
+			Required:  policy.MinApprovals,
+			Approved:  len(list) >= policy.MinApprovals,
+		}, 200)
+	}
+}
+
+// HandleApprove returns an http.HandlerFunc that processes http
+// requests to approve a blocked build. The approval is recorded
+// and, once the approval policy of the pipeline is satisfied,
+// the blocked stages of the build are scheduled. Each stage is
+// only scheduled by the request that unblocked it.
+func HandleApprove(
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	stages core.StageStore,
+	perms core.PermStore,
+	pipelineStore model.PipelineStore,
+	approvals model.ApprovalStore,
+	sched core.Scheduler,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			user, _ = request.UserFrom(ctx)
+		)
+		repo, build, ok := findBuild(w, r, repos, builds)
+		if !ok {
+			return
+		}
+		list, err := stages.List(ctx, build.ID)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		var blocked []*core.Stage
+		for _, stage := range list {
+			if stage.Status == core.StatusBlocked {
+				blocked = append(blocked, stage)
+			}
+		}
+		if len(blocked) == 0 {
+			render.BadRequestf(w, "Cannot approve a build with Status %q", build.Status)
+			return
+		}
+
+		policy, err := findPolicy(ctx, pipelineStore, repo, build)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		if !canApprove(ctx, perms, policy, repo, user) {
+			render.Forbidden(w, errApproverRequired)
+			return
+		}
+
+		prev, err := approvals.List(ctx, build.ID)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		for _, approval := range prev {
+			if approval.User == user.Login {
+				render.BadRequestf(w, "Build already approved by %s", user.Login)
+				return
+			}
+		}
+		approval := &model.Approval{
+			BuildID: build.ID,
+			Slug:    repo.Slug,
+			Number:  build.Number,
+			User:    user.Login,
+			Created: time.Now().Unix(),
+		}
+		err = approvals.Create(ctx, approval)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		// the approvals are listed again, since they may have
+		// been recorded by concurrent requests.
+		approved, err := approvals.List(ctx, build.ID)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+
+		status := &approvalStatus{
+			Approvals: approved,
+			Required:  policy.MinApprovals,
+			Approved:  len(approved) >= policy.MinApprovals,
+		}
+		if !status.Approved {
+			render.JSON(w, status, 200)
+			return
+		}
+
+		for _, stage := range blocked {
+			// stages with dependencies wait for their
+			// dependencies to complete.
+			stage.Status = core.StatusWaiting
+			if len(stage.DependsOn) == 0 {
+				stage.Status = core.StatusPending
+			}
+			// the update fails if the stage is no longer
+			// blocked, for example because it was approved by
+			// a concurrent request, which schedules the stage.
+			err = stages.Update(ctx, stage)
+			if err == db.ErrOptimisticLock {
+				continue
+			}
+			if err != nil {
+				logrus.WithError(err).Errorln("builds: cannot approve the stage")
+				render.InternalErrorf(w, "There was a problem approving the Pipeline")
+				return
+			}
+			if stage.Status != core.StatusPending {
+				continue
+			}
+			err = sched.Schedule(ctx, stage)
+			if err != nil {
+				logrus.WithError(err).Errorln("builds: cannot schedule the stage")
+				render.InternalErrorf(w, "There was a problem scheduling the Pipeline")
+				return
+			}
+		}
+		render.JSON(w, status, 200)
+	}
+}
+
+// findBuild returns the repository and build of the request.
+// If not found, the error is written to the response.
+func findBuild(w http.ResponseWriter, r *http.Request, repos core.RepositoryStore, builds core.BuildStore) (*core.Repository, *core.Build, bool) {
+	var (
+		ctx       = r.Context()
+		namespace = chi.URLParam(r, "owner")
+		name      = chi.URLParam(r, "name")
+	)
+	number, err := strconv.ParseInt(chi.URLParam(r, "number"), 10, 64)
+	if err != nil {
+		render.BadRequestf(w, "Invalid build number")
+		return nil, nil, false
+	}
+	repo, err := repos.FindName(ctx, namespace, name)
+	if err != nil {
+		render.NotFoundf(w, "Repository not found")
+		return nil, nil, false
+	}
+	build, err := builds.FindNumber(ctx, repo.ID, number)
+	if err != nil {
+		render.NotFoundf(w, "Build not found")
+		return nil, nil, false
+	}
+	return repo, build, true
+}
+
+// findPolicy returns the approval policy of the build. If the
+// pipeline has no approval policy, for example because it was
+// removed after the build was blocked, a single approval by a
+// repository admin is required.
+func findPolicy(ctx context.Context, pipelineStore model.PipelineStore, repo *core.Repository, build *core.Build) (*model.ApprovalPolicy, error) {
+	pipe, err := pipelineStore.FindPipeline(ctx, repo, build.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if pipe == nil || pipe.Approval == nil {
+		return &model.ApprovalPolicy{MinApprovals: 1}, nil
+	}
+	return pipe.Approval, nil
+}
+
+// canApprove returns true if the user is an approver of the
+// policy or, if the policy has no approvers, a repository admin.
+func canApprove(ctx context.Context, perms core.PermStore, policy *model.ApprovalPolicy, repo *core.Repository, user *core.User) bool {
+	if len(policy.Approvers) != 0 {
+		return policy.IsApprover(user.Login)
+	}
+	if user.Admin {
+		return true
+	}
+	perm, err := perms.Find(ctx, repo.UID, user.ID)
+	if err != nil {
+		return false
+	}
+	return perm.Admin
+}
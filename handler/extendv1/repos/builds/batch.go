@@ -0,0 +1,260 @@
+package builds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/go-scm/scm"
+)
+
+// batch concurrency limits.
+const (
+	defaultConcurrency = 4
+	maxConcurrency     = 20
+)
+
+// batch result states.
+const (
+	batchTriggered = "triggered"
+	batchDryRun    = "dry_run"
+	batchFailed    = "failed"
+)
+
+var errEmptySelector = errors.New("A repository selector (org, name or template) is required")
+
+type (
+	// BatchInput selects the repositories to build. The
+	// selectors are combined, a repository must match all
+	// of the provided selectors.
+	BatchInput struct {
+		Org         string            `json:"org"`
+		Name        string            `json:"name"`
+		Template    string            `json:"template"`
+		Branch      string            `json:"branch"`
+		Params      map[string]string `json:"params"`
+		DryRun      bool              `json:"dry_run"`
+		Concurrency int               `json:"concurrency"`
+	}
+
+	// BatchResult reports the result of the build of a
+	// single repository.
+	BatchResult struct {
+		Slug   string `json:"slug"`
+		Status string `json:"status"`
+		Number int64  `json:"number,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// HandleBatch returns an http.HandlerFunc that processes http
+// requests to build the default (or given) branch of all
+// repositories matching the selector. The builds are triggered
+// concurrently and a per repository report is returned.
+func HandleBatch(
+	users core.UserStore,
+	repos core.RepositoryStore,
+	perms core.PermStore,
+	commits core.CommitService,
+	pipelineStore model.PipelineStore,
+	triggerer core.Triggerer,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			user, _ = request.UserFrom(ctx)
+		)
+		in := new(BatchInput)
+		err := json.NewDecoder(r.Body).Decode(in)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+		if in.Org == "" && in.Name == "" && in.Template == "" {
+			render.BadRequest(w, errEmptySelector)
+			return
+		}
+		if _, err := path.Match(in.Name, ""); err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+
+		selected, err := selectRepos(ctx, repos, pipelineStore, user, in)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+
+		concurrency := in.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultConcurrency
+		}
+		if concurrency > maxConcurrency {
+			concurrency = maxConcurrency
+		}
+
+		var (
+			wg      sync.WaitGroup
+			sem     = make(chan struct{}, concurrency)
+			results = make([]*BatchResult, len(selected))
+		)
+		for i, repo := range selected {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, repo *core.Repository) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = batchBuild(ctx, users, perms, commits, pipelineStore, triggerer, user, repo, in)
+			}(i, repo)
+		}
+		wg.Wait()
+		render.JSON(w, results, 200)
+	}
+}
+
+// selectRepos returns the active repositories of the user that
+// match the selector, sorted by slug.
+func selectRepos(ctx context.Context, repos core.RepositoryStore, pipelineStore model.PipelineStore, user *core.User, in *BatchInput) ([]*core.Repository, error) {
+	var list []*core.Repository
+	if user.Admin {
+		for offset := 0; ; offset += 1000 {
+			page, err := repos.ListAll(ctx, 1000, offset)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, page...)
+			if len(page) < 1000 {
+				break
+			}
+		}
+	} else {
+		var err error
+		list, err = repos.List(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var templated map[string]struct{}
+	if in.Template != "" {
+		pipes, err := pipelineStore.ListByTemplate(ctx, in.Template)
+		if err != nil {
+			return nil, err
+		}
+		templated = map[string]struct{}{}
+		for _, pipe := range pipes {
+			templated[pipe.Slug] = struct{}{}
+		}
+	}
+
+	var selected []*core.Repository
+	for _, repo := range list {
+		if !repo.Active {
+			continue
+		}
+		if in.Org != "" && repo.Namespace != in.Org {
+			continue
+		}
+		if in.Name != "" {
+			if ok, _ := path.Match(in.Name, repo.Name); !ok {
+				continue
+			}
+		}
+		if templated != nil {
+			if _, ok := templated[repo.Slug]; !ok {
+				continue
+			}
+		}
+		selected = append(selected, repo)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Slug < selected[j].Slug
+	})
+	return selected, nil
+}
+
+// batchBuild triggers the build of a single repository of the
+// batch. In dry run mode all checks are performed, but the
+// build is not triggered.
+func batchBuild(
+	ctx context.Context,
+	users core.UserStore,
+	perms core.PermStore,
+	commits core.CommitService,
+	pipelineStore model.PipelineStore,
+	triggerer core.Triggerer,
+	user *core.User,
+	repo *core.Repository,
+	in *BatchInput,
+) *BatchResult {
+	result := &BatchResult{Slug: repo.Slug}
+	fail := func(err error) *BatchResult {
+		result.Status = batchFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	if !user.Admin {
+		perm, err := perms.Find(ctx, repo.UID, user.ID)
+		if err != nil {
+			return fail(err)
+		}
+		if !perm.Write && !perm.Admin {
+			return fail(errors.New("Write access to the repository is required"))
+		}
+	}
+
+	owner, err := users.Find(ctx, repo.UserID)
+	if err != nil {
+		return fail(err)
+	}
+	branch := in.Branch
+	if branch == "" {
+		branch = repo.Branch
+	}
+	ref := scm.ExpandRef(branch, "refs/heads")
+	commit, err := commits.FindRef(ctx, owner, repo.Slug, ref)
+	if err != nil {
+		return fail(err)
+	}
+
+	hook := newHook(user, commit, core.EventPush, ref, branch)
+	for key, value := range in.Params {
+		hook.Params[key] = value
+	}
+	pipe, err := pipelineStore.FindPipeline(ctx, repo, ref)
+	if err != nil {
+		return fail(err)
+	}
+	if pipe != nil && len(pipe.Params) != 0 {
+		hook.Params, err = model.ResolveParams(pipe.Params, hook.Params)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if in.DryRun {
+		result.Status = batchDryRun
+		return result
+	}
+	build, err := triggerer.Trigger(ctx, repo, hook)
+	if err != nil {
+		return fail(err)
+	}
+	result.Status = batchTriggered
+	if build != nil {
+		result.Number = build.Number
+	}
+	return result
+}
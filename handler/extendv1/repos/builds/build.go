@@ -1,24 +1,57 @@
 package builds
 
 import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
 
 	"github.com/drone/drone/core"
 	"github.com/drone/drone/handler/api/render"
 	"github.com/drone/drone/handler/api/request"
 	"github.com/drone/go-scm/scm"
 	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidPull = errors.New("Invalid pull request number")
+	errInvalidRef  = errors.New("Invalid git reference, must start with refs/")
 )
 
 // HandleCreate returns an http.HandlerFunc that processes http
-// requests to create a build for the specified commit.
+// requests to create a build for the specified commit. The build
+// is created for a branch, a tag, a pull request number or any
+// fully qualified git reference. If the pipeline declares build
+// parameters, the submitted parameters are validated against the
+// declaration. If the request body contains a pipeline, the
+// build uses it instead of the stored pipeline.
 func HandleCreate(
 	users core.UserStore,
 	repos core.RepositoryStore,
 	commits core.CommitService,
+	gits model.GitService,
+	pipelineStore model.PipelineStore,
+	overrideStore model.OverrideStore,
 	triggerer core.Triggerer,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// the body is read before the form values are parsed,
+		// since it contains the pipeline and not form data.
+		override, err := ioutil.ReadAll(
+			io.LimitReader(r.Body, 10000000),
+		)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+
 		var (
 			ctx       = r.Context()
 			namespace = chi.URLParam(r, "owner")
@@ -26,6 +59,8 @@ func HandleCreate(
 			sha       = r.FormValue("commit")
 			branch    = r.FormValue("branch")
 			tag       = r.FormValue("tag")
+			pull      = r.FormValue("pull")
+			ref       = r.FormValue("ref")
 			user, _   = request.UserFrom(ctx)
 		)
 
@@ -41,54 +76,106 @@ func HandleCreate(
 			return
 		}
 
-		// if the user does not provide a branch, assume the
-		// default repository branch.
-		if branch == "" {
-			branch = repo.Branch
+		if ref != "" && !strings.HasPrefix(ref, "refs/") {
+			render.BadRequest(w, errInvalidRef)
+			return
 		}
-		// expand the branch to a git reference.
-		ref := scm.ExpandRef(branch, "refs/heads")
-		event := core.EventPush
-		if tag != "" {
-			ref = scm.ExpandRef(tag, "refs/tags")
-			event = core.EventTag
+		// a pull request reference is built as a pull request.
+		if pull == "" && scm.IsPullRequest(ref) {
+			pull = strconv.Itoa(scm.ExtractPullRequest(ref))
 		}
 
-		var commit *core.Commit
-		if sha != "" {
-			commit, err = commits.Find(ctx, owner, repo.Slug, sha)
+		var hook *core.Hook
+		if pull != "" {
+			number, err := strconv.Atoi(pull)
+			if err != nil || number <= 0 {
+				render.BadRequest(w, errInvalidPull)
+				return
+			}
+			pr, err := gits.FindPullRequest(ctx, owner, repo.Slug, number)
+			if err != nil {
+				render.NotFound(w, err)
+				return
+			}
+			commit, err := commits.Find(ctx, owner, repo.Slug, pr.Sha)
+			if err != nil {
+				render.NotFound(w, err)
+				return
+			}
+			hook = &core.Hook{
+				Trigger:      user.Login,
+				Event:        core.EventPullRequest,
+				Action:       core.ActionSync,
+				Link:         pr.Link,
+				Timestamp:    commit.Author.Date,
+				Title:        pr.Title,
+				Message:      commit.Message,
+				Before:       pr.Base.Sha,
+				After:        pr.Sha,
+				Ref:          pr.Ref,
+				Fork:         pr.Fork,
+				Source:       pr.Source,
+				Target:       pr.Target,
+				Author:       commit.Author.Login,
+				AuthorName:   commit.Author.Name,
+				AuthorEmail:  commit.Author.Email,
+				AuthorAvatar: commit.Author.Avatar,
+				Sender:       user.Login,
+				Params:       map[string]string{},
+			}
 		} else {
-			commit, err = commits.FindRef(ctx, owner, repo.Slug, ref)
-		}
-		if err != nil {
-			render.NotFound(w, err)
-			return
-		}
+			event := core.EventPush
+			switch {
+			case ref != "":
+				// the event is derived from the fully
+				// qualified reference.
+				switch {
+				case scm.IsTag(ref):
+					event = core.EventTag
+				case !scm.IsBranch(ref):
+					event = core.EventCustom
+				}
+				branch = scm.TrimRef(ref)
+			case tag != "":
+				ref = scm.ExpandRef(tag, "refs/tags")
+				event = core.EventTag
+				if branch == "" {
+					branch = repo.Branch
+				}
+			default:
+				// if the user does not provide a branch, assume
+				// the default repository branch.
+				if branch == "" {
+					branch = repo.Branch
+				}
+				// expand the branch to a git reference.
+				ref = scm.ExpandRef(branch, "refs/heads")
+			}
+
+			var commit *core.Commit
+			switch {
+			case sha != "":
+				commit, err = commits.Find(ctx, owner, repo.Slug, sha)
+			case event == core.EventCustom:
+				commit, err = commits.Find(ctx, owner, repo.Slug, ref)
+			default:
+				commit, err = commits.FindRef(ctx, owner, repo.Slug, ref)
+			}
+			if err != nil {
+				render.NotFound(w, err)
+				return
+			}
 
-		hook := &core.Hook{
-			Trigger:      user.Login,
-			Event:        event,
-			Link:         commit.Link,
-			Timestamp:    commit.Author.Date,
-			Title:        "", // we expect this to be empty.
-			Message:      commit.Message,
-			Before:       commit.Sha,
-			After:        commit.Sha,
-			Ref:          ref,
-			Source:       branch,
-			Target:       branch,
-			Author:       commit.Author.Login,
-			AuthorName:   commit.Author.Name,
-			AuthorEmail:  commit.Author.Email,
-			AuthorAvatar: commit.Author.Avatar,
-			Sender:       user.Login,
-			Params:       map[string]string{},
+			hook = newHook(user, commit, event, ref, branch)
 		}
 
 		for key, value := range r.URL.Query() {
 			if key == "access_token" ||
 				key == "commit" ||
-				key == "branch" {
+				key == "branch" ||
+				key == "tag" ||
+				key == "pull" ||
+				key == "ref" {
 				continue
 			}
 			if len(value) == 0 {
@@ -97,11 +184,91 @@ func HandleCreate(
 			hook.Params[key] = value[0]
 		}
 
-		result, err := triggerer.Trigger(r.Context(), repo, hook)
+		pipe, err := pipelineStore.FindPipeline(ctx, repo, hook.Ref)
 		if err != nil {
 			render.InternalError(w, err)
-		} else {
-			render.JSON(w, result, 200)
+			return
+		}
+		if pipe != nil && len(pipe.Params) != 0 {
+			hook.Params, err = model.ResolveParams(pipe.Params, hook.Params)
+			if err != nil {
+				render.BadRequest(w, err)
+				return
+			}
+		}
+
+		var o *model.Override
+		if strings.TrimSpace(string(override)) != "" {
+			o = &model.Override{
+				UUID:    uuid.New().String(),
+				Slug:    repo.Slug,
+				Content: string(override),
+				Creator: user.Login,
+				Created: time.Now().Unix(),
+			}
+		}
+		result, err := trigger(ctx, overrideStore, triggerer, repo, hook, o)
+		if err != nil {
+			render.InternalError(w, err)
+			return
 		}
+		render.JSON(w, result, 200)
+	}
+}
+
+// trigger triggers the build with the pipeline override, if
+// any. The override is passed to the triggerer in the context.
+// It is stored before the build is triggered and linked to the
+// build once the build is created, so a build never runs an
+// override that is not recorded.
+func trigger(
+	ctx context.Context,
+	overrideStore model.OverrideStore,
+	triggerer core.Triggerer,
+	repo *core.Repository,
+	hook *core.Hook,
+	override *model.Override,
+) (*core.Build, error) {
+	if override == nil {
+		return triggerer.Trigger(ctx, repo, hook)
+	}
+	err := overrideStore.CreateOverride(ctx, override)
+	if err != nil {
+		return nil, err
+	}
+	result, err := triggerer.Trigger(model.WithOverride(ctx, override), repo, hook)
+	if err != nil || result == nil {
+		return result, err
+	}
+	override.BuildID = result.ID
+	override.Number = result.Number
+	err = overrideStore.LinkOverride(ctx, override)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// newHook returns a hook that builds the commit of a branch,
+// tag or other git reference.
+func newHook(user *core.User, commit *core.Commit, event, ref, branch string) *core.Hook {
+	return &core.Hook{
+		Trigger:      user.Login,
+		Event:        event,
+		Link:         commit.Link,
+		Timestamp:    commit.Author.Date,
+		Title:        "", // we expect this to be empty.
+		Message:      commit.Message,
+		Before:       commit.Sha,
+		After:        commit.Sha,
+		Ref:          ref,
+		Source:       branch,
+		Target:       branch,
+		Author:       commit.Author.Login,
+		AuthorName:   commit.Author.Name,
+		AuthorEmail:  commit.Author.Email,
+		AuthorAvatar: commit.Author.Avatar,
+		Sender:       user.Login,
+		Params:       map[string]string{},
 	}
 }
@@ -0,0 +1,126 @@
+package builds
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/go-chi/chi"
+)
+
+func TestCreate_Tag(t *testing.T) {
+	repo := &core.Repository{ID: 1, UserID: 1, Slug: "octocat/hello-world", Branch: "master"}
+	pipes := &pipelineStore{
+		pipe: &model.Pipeline{
+			Params: []*model.Param{{Name: "target", Type: "string", Default: "staging"}},
+		},
+	}
+	triggerer := new(triggerer)
+	handler := HandleCreate(
+		userStore{},
+		repoStore{repo: repo},
+		commitService{},
+		nil,
+		pipes,
+		nil,
+		triggerer,
+	)
+
+	c := new(chi.Context)
+	c.URLParams.Add("owner", "octocat")
+	c.URLParams.Add("name", "hello-world")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/?tag=v1.0.0&target=prod", nil)
+	r = r.WithContext(
+		context.WithValue(request.WithUser(r.Context(), &core.User{Login: "octocat"}), chi.RouteCtxKey, c),
+	)
+	handler(w, r)
+
+	if got, want := w.Code, 200; got != want {
+		t.Fatalf("Want response code %d, got %d: %s", want, got, w.Body)
+	}
+	if got, want := triggerer.hook.Ref, "refs/tags/v1.0.0"; got != want {
+		t.Errorf("Want ref %s, got %s", want, got)
+	}
+	if got, want := triggerer.hook.Event, core.EventTag; got != want {
+		t.Errorf("Want event %s, got %s", want, got)
+	}
+	if got := triggerer.hook.Params; len(got) != 1 || got["target"] != "prod" {
+		t.Errorf("Want only the declared parameters, got %v", got)
+	}
+}
+
+type userStore struct {
+	core.UserStore
+}
+
+func (userStore) Find(context.Context, int64) (*core.User, error) {
+	return &core.User{ID: 1}, nil
+}
+
+type repoStore struct {
+	core.RepositoryStore
+	repo *core.Repository
+}
+
+func (s repoStore) FindName(context.Context, string, string) (*core.Repository, error) {
+	return s.repo, nil
+}
+
+type commitService struct {
+	core.CommitService
+}
+
+func (commitService) FindRef(ctx context.Context, user *core.User, repo, ref string) (*core.Commit, error) {
+	return &core.Commit{Sha: "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", Ref: ref, Author: &core.Committer{Login: "octocat"}}, nil
+}
+
+type pipelineStore struct {
+	model.PipelineStore
+	pipe *model.Pipeline
+}
+
+func (s *pipelineStore) FindPipeline(context.Context, *core.Repository, string) (*model.Pipeline, error) {
+	return s.pipe, nil
+}
+
+type overrideStore struct {
+	model.OverrideStore
+	overrides map[int64]*model.Override
+	created   []*model.Override
+	linked    []*model.Override
+}
+
+func (s *overrideStore) GetOverride(ctx context.Context, buildID int64) (*model.Override, bool, error) {
+	override, ok := s.overrides[buildID]
+	return override, ok, nil
+}
+
+func (s *overrideStore) CreateOverride(ctx context.Context, override *model.Override) error {
+	s.created = append(s.created, override)
+	return nil
+}
+
+func (s *overrideStore) LinkOverride(ctx context.Context, override *model.Override) error {
+	s.linked = append(s.linked, override)
+	return nil
+}
+
+type triggerer struct {
+	hook      *core.Hook
+	override  *model.Override
+	overrides *overrideStore
+	stored    bool
+}
+
+func (t *triggerer) Trigger(ctx context.Context, repo *core.Repository, hook *core.Hook) (*core.Build, error) {
+	t.hook = hook
+	t.override, _ = model.OverrideFrom(ctx)
+	t.stored = t.overrides != nil && len(t.overrides.created) != 0
+	return &core.Build{ID: 1, Number: 1}, nil
+}
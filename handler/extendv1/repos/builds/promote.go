@@ -0,0 +1,285 @@
+package builds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+var (
+	errTargetRequired     = errors.New("Missing target environment")
+	errRevisionNotFound   = errors.New("Cannot find the pipeline used by the build")
+	errNoRollbackTarget   = errors.New("No successful deployment to roll back to")
+	errInvalidDeployment  = errors.New("Build is not a successful deployment to the target environment")
+	errDeploymentNotFound = errors.New("Deployment not found")
+)
+
+// HandlePromote returns an http.HandlerFunc that processes http
+// requests to promote a build to the target environment. The
+// promoted build uses the commit and the pipeline revision of
+// the original build.
+func HandlePromote(
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	pipelineStore model.PipelineStore,
+	overrideStore model.OverrideStore,
+	triggerer core.Triggerer,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			target  = r.FormValue("target")
+			user, _ = request.UserFrom(ctx)
+		)
+		if target == "" {
+			render.BadRequest(w, errTargetRequired)
+			return
+		}
+		repo, prev, ok := findBuild(w, r, repos, builds)
+		if !ok {
+			return
+		}
+		result, err := deploy(ctx, pipelineStore, overrideStore, triggerer, user, repo, prev, core.EventPromote, target, r)
+		if err == errRevisionNotFound {
+			render.NotFound(w, err)
+			return
+		}
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, result, 200)
+	}
+}
+
+// HandleRollback returns an http.HandlerFunc that processes http
+// requests to roll back the target environment to the previous
+// successful deployment, or to the deployment with the given
+// build number.
+func HandleRollback(
+	repos core.RepositoryStore,
+	deployments model.DeploymentStore,
+	pipelineStore model.PipelineStore,
+	overrideStore model.OverrideStore,
+	triggerer core.Triggerer,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			target  = r.FormValue("target")
+			number  = r.FormValue("number")
+			user, _ = request.UserFrom(ctx)
+		)
+		if target == "" {
+			render.BadRequest(w, errTargetRequired)
+			return
+		}
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFoundf(w, "Repository not found")
+			return
+		}
+		list, err := deployments.List(ctx, repo.ID, target)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+
+		var prev *core.Build
+		if number != "" {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil {
+				render.BadRequestf(w, "Invalid build number")
+				return
+			}
+			for _, build := range list {
+				if build.Number == n {
+					prev = build
+				}
+			}
+			if prev == nil || prev.Status != core.StatusPassing {
+				render.BadRequest(w, errInvalidDeployment)
+				return
+			}
+		} else {
+			prev = rollbackTarget(list)
+			if prev == nil {
+				render.NotFound(w, errNoRollbackTarget)
+				return
+			}
+		}
+
+		result, err := deploy(ctx, pipelineStore, overrideStore, triggerer, user, repo, prev, core.EventRollback, target, r)
+		if err == errRevisionNotFound {
+			render.NotFound(w, err)
+			return
+		}
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, result, 200)
+	}
+}
+
+// HandleDeployments returns an http.HandlerFunc that writes the
+// json-encoded deployment history of the repository, optionally
+// filtered by target environment, to the response body.
+func HandleDeployments(
+	repos core.RepositoryStore,
+	deployments model.DeploymentStore,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx    = r.Context()
+			target = r.FormValue("target")
+		)
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFoundf(w, "Repository not found")
+			return
+		}
+		list, err := deployments.List(ctx, repo.ID, target)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, list, 200)
+	}
+}
+
+// deploy triggers a promote or rollback build of the previous
+// build. The pipeline revision used by the previous build is
+// attached to the new build as a pipeline override.
+func deploy(
+	ctx context.Context,
+	pipelineStore model.PipelineStore,
+	overrideStore model.OverrideStore,
+	triggerer core.Triggerer,
+	user *core.User,
+	repo *core.Repository,
+	prev *core.Build,
+	event string,
+	target string,
+	r *http.Request,
+) (*core.Build, error) {
+	content, err := buildPipeline(ctx, pipelineStore, overrideStore, repo, prev)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &core.Hook{
+		Parent:       prev.Number,
+		Trigger:      user.Login,
+		Event:        event,
+		Action:       prev.Action,
+		Link:         prev.Link,
+		Timestamp:    prev.Timestamp,
+		Title:        prev.Title,
+		Message:      prev.Message,
+		Before:       prev.Before,
+		After:        prev.After,
+		Ref:          prev.Ref,
+		Fork:         prev.Fork,
+		Source:       prev.Source,
+		Target:       prev.Target,
+		Author:       prev.Author,
+		AuthorName:   prev.AuthorName,
+		AuthorEmail:  prev.AuthorEmail,
+		AuthorAvatar: prev.AuthorAvatar,
+		Deployment:   target,
+		Cron:         prev.Cron,
+		Sender:       prev.Sender,
+		Params:       map[string]string{},
+	}
+	for key, value := range prev.Params {
+		hook.Params[key] = value
+	}
+	for key, value := range r.URL.Query() {
+		if key == "access_token" ||
+			key == "target" ||
+			key == "number" {
+			continue
+		}
+		if len(value) == 0 {
+			continue
+		}
+		hook.Params[key] = value[0]
+	}
+
+	override := &model.Override{
+		UUID:    uuid.New().String(),
+		Slug:    repo.Slug,
+		Content: content,
+		Creator: user.Login,
+		Created: time.Now().Unix(),
+	}
+	return trigger(ctx, overrideStore, triggerer, repo, hook, override)
+}
+
+// buildPipeline returns the pipeline used by the build: the
+// pipeline override of the build, if any, otherwise the stored
+// pipeline revision that was current when the build was created.
+func buildPipeline(ctx context.Context, pipelineStore model.PipelineStore, overrideStore model.OverrideStore, repo *core.Repository, build *core.Build) (string, error) {
+	override, isExist, err := overrideStore.GetOverride(ctx, build.ID)
+	if err != nil {
+		return "", err
+	}
+	if isExist && override.Slug == repo.Slug {
+		return override.Content, nil
+	}
+	revision, err := pipelineStore.FindRevision(ctx, repo.ID, build.Ref, build.Created)
+	if err != nil {
+		return "", err
+	}
+	if revision == nil {
+		return "", errRevisionNotFound
+	}
+	return revision.Content, nil
+}
+
+// rollbackTarget returns the deployment to roll back to: the
+// most recent successful deployment before the one currently
+// deployed. A rollback deploys its parent, so the deployments
+// after the parent of a rollback were undone and are skipped,
+// as are the rollback builds themselves.
+func rollbackTarget(deployments []*core.Build) *core.Build {
+	if len(deployments) == 0 {
+		return nil
+	}
+	numbers := map[int64]*core.Build{}
+	for _, build := range deployments {
+		numbers[build.Number] = build
+	}
+	current := deployments[0]
+	for current.Event == core.EventRollback && numbers[current.Parent] != nil {
+		current = numbers[current.Parent]
+	}
+
+	var undone int64
+	for _, build := range deployments {
+		if build.Event == core.EventRollback {
+			if undone == 0 || build.Parent < undone {
+				undone = build.Parent
+			}
+			continue
+		}
+		if build == current || (undone != 0 && build.Number > undone) {
+			continue
+		}
+		if build.Status == core.StatusPassing {
+			return build
+		}
+	}
+	return nil
+}
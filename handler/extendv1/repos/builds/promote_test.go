@@ -0,0 +1,40 @@
+package builds
+
+import (
+	"testing"
+
+	"github.com/drone/drone/core"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	promote := func(number int64, status string) *core.Build {
+		return &core.Build{Number: number, Event: core.EventPromote, Status: status}
+	}
+	rollback := func(number, parent int64) *core.Build {
+		return &core.Build{Number: number, Parent: parent, Event: core.EventRollback, Status: core.StatusPassing}
+	}
+	tests := []struct {
+		deployments []*core.Build
+		want        int64
+	}{
+		// the deployment before the current one.
+		{[]*core.Build{promote(3, core.StatusPassing), promote(2, core.StatusPassing), promote(1, core.StatusPassing)}, 2},
+		// the last successful deployment if the current one failed.
+		{[]*core.Build{promote(3, core.StatusFailing), promote(2, core.StatusFailing), promote(1, core.StatusPassing)}, 1},
+		// a second rollback does not redeploy the rolled back build.
+		{[]*core.Build{rollback(4, 2), promote(3, core.StatusPassing), promote(2, core.StatusPassing), promote(1, core.StatusPassing)}, 1},
+		{[]*core.Build{rollback(5, 1), rollback(4, 2), promote(3, core.StatusPassing), promote(2, core.StatusPassing), promote(1, core.StatusPassing)}, 0},
+		// deployments undone by an earlier rollback are skipped.
+		{[]*core.Build{promote(5, core.StatusFailing), rollback(4, 1), promote(3, core.StatusPassing), promote(2, core.StatusPassing), promote(1, core.StatusPassing)}, 1},
+		{nil, 0},
+	}
+	for i, test := range tests {
+		var got int64
+		if build := rollbackTarget(test.deployments); build != nil {
+			got = build.Number
+		}
+		if got != test.want {
+			t.Errorf("Want rollback target %d, got %d at index %d", test.want, got, i)
+		}
+	}
+}
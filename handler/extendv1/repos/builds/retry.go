@@ -0,0 +1,105 @@
+package builds
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/google/uuid"
+)
+
+// HandleRetry returns an http.HandlerFunc that processes http
+// requests to restart a build. It serves the build restart
+// endpoint of the drone api, so a restarted build runs the
+// pipeline override of the build it restarts, if any.
+func HandleRetry(
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	overrideStore model.OverrideStore,
+	triggerer core.Triggerer,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			user, _ = request.UserFrom(ctx)
+		)
+		repo, prev, ok := findBuild(w, r, repos, builds)
+		if !ok {
+			return
+		}
+
+		switch prev.Status {
+		case core.StatusBlocked:
+			render.BadRequestf(w, "cannot start a blocked build")
+			return
+		case core.StatusDeclined:
+			render.BadRequestf(w, "cannot start a declined build")
+			return
+		}
+
+		hook := &core.Hook{
+			Trigger:      user.Login,
+			Event:        prev.Event,
+			Action:       prev.Action,
+			Link:         prev.Link,
+			Timestamp:    prev.Timestamp,
+			Title:        prev.Title,
+			Message:      prev.Message,
+			Before:       prev.Before,
+			After:        prev.After,
+			Ref:          prev.Ref,
+			Fork:         prev.Fork,
+			Source:       prev.Source,
+			Target:       prev.Target,
+			Author:       prev.Author,
+			AuthorName:   prev.AuthorName,
+			AuthorEmail:  prev.AuthorEmail,
+			AuthorAvatar: prev.AuthorAvatar,
+			Deployment:   prev.Deploy,
+			DeploymentID: prev.DeployID,
+			Cron:         prev.Cron,
+			Sender:       prev.Sender,
+			Params:       map[string]string{},
+		}
+		for key, value := range r.URL.Query() {
+			if key == "access_token" {
+				continue
+			}
+			if len(value) == 0 {
+				continue
+			}
+			hook.Params[key] = value[0]
+		}
+		for key, value := range prev.Params {
+			hook.Params[key] = value
+		}
+
+		// the override of the previous build is copied, so the
+		// restarted build is recorded as using an override.
+		prevOverride, isExist, err := overrideStore.GetOverride(ctx, prev.ID)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		var o *model.Override
+		if isExist {
+			o = &model.Override{
+				UUID:    uuid.New().String(),
+				Slug:    repo.Slug,
+				Content: prevOverride.Content,
+				Creator: user.Login,
+				Created: time.Now().Unix(),
+			}
+		}
+		result, err := trigger(ctx, overrideStore, triggerer, repo, hook, o)
+		if err != nil {
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, result, 200)
+	}
+}
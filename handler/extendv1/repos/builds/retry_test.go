@@ -0,0 +1,62 @@
+package builds
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/go-chi/chi"
+)
+
+func TestRetry_Override(t *testing.T) {
+	repo := &core.Repository{ID: 1, Slug: "octocat/hello-world"}
+	prev := &core.Build{ID: 3, Number: 3, RepoID: 1, Event: core.EventPush, Status: core.StatusFailing}
+	overrides := &overrideStore{
+		overrides: map[int64]*model.Override{
+			3: {UUID: "3e5a1b9c", Slug: repo.Slug, BuildID: 3, Number: 3, Content: "kind: pipeline"},
+		},
+	}
+	triggerer := &triggerer{overrides: overrides}
+	handler := HandleRetry(repoStore{repo: repo}, buildStore{build: prev}, overrides, triggerer)
+
+	c := new(chi.Context)
+	c.URLParams.Add("owner", "octocat")
+	c.URLParams.Add("name", "hello-world")
+	c.URLParams.Add("number", "3")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", nil)
+	r = r.WithContext(
+		context.WithValue(request.WithUser(r.Context(), &core.User{Login: "octocat"}), chi.RouteCtxKey, c),
+	)
+	handler(w, r)
+
+	if got, want := w.Code, 200; got != want {
+		t.Fatalf("Want response code %d, got %d: %s", want, got, w.Body)
+	}
+	if triggerer.override == nil || triggerer.override.Content != "kind: pipeline" {
+		t.Fatalf("Want the override of the restarted build, got %+v", triggerer.override)
+	}
+	if triggerer.override.UUID == "3e5a1b9c" {
+		t.Errorf("Want a new override recorded for the restarted build")
+	}
+	if !triggerer.stored {
+		t.Errorf("Want the override stored before the build is triggered")
+	}
+	if len(overrides.linked) != 1 || overrides.linked[0].BuildID != 1 {
+		t.Errorf("Want the override linked to the restarted build")
+	}
+}
+
+type buildStore struct {
+	core.BuildStore
+	build *core.Build
+}
+
+func (s buildStore) FindNumber(context.Context, int64, int64) (*core.Build, error) {
+	return s.build, nil
+}
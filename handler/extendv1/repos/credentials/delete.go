@@ -0,0 +1,45 @@
+package credentials
+
+import (
+	"net/http"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+)
+
+// HandleDelete returns an http.HandlerFunc that processes http
+// requests to reset the clone credential of the repository to
+// the token of the user that triggered the build.
+func HandleDelete(
+	creds model.CredentialStore,
+	secrets core.SecretStore,
+	gits model.GitService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			user, _ = request.UserFrom(ctx)
+			repo, _ = request.RepoFrom(ctx)
+		)
+		prev, _, err := creds.Find(ctx, repo.Slug)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		err = creds.Delete(ctx, repo.Slug)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if prev != nil && prev.KeyID != "" {
+			revokeKey(ctx, gits, secrets, user, repo, prev)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
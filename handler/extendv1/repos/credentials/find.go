@@ -0,0 +1,34 @@
+package credentials
+
+import (
+	"net/http"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+)
+
+// HandleFind returns an http.HandlerFunc that writes json-encoded
+// clone credential of the repository to the response body. If the
+// repository has no credential, the token credential is returned.
+func HandleFind(creds model.CredentialStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		repo, _ := request.RepoFrom(ctx)
+		cred, isExist, err := creds.Find(ctx, repo.Slug)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			cred = &model.Credential{
+				Slug: repo.Slug,
+				Type: model.CredentialToken,
+			}
+		}
+		render.JSON(w, cred, 200)
+	}
+}
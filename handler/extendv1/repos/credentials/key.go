@@ -0,0 +1,27 @@
+package credentials
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+)
+
+// revokeKey removes the previous deploy key from the repository
+// and the secret that holds the private key. Failures are logged,
+// since the credential has already been replaced.
+func revokeKey(ctx context.Context, gits model.GitService, secrets core.SecretStore, user *core.User, repo *core.Repository, prev *model.Credential) {
+	log := logrus.WithField("repo", repo.Slug).WithField("key", prev.KeyID)
+	if err := gits.DeleteDeployKey(ctx, user, repo.Slug, prev.KeyID); err != nil {
+		log.WithError(err).Warnln("credentials: cannot delete deploy key")
+	}
+	secret, err := secrets.FindName(ctx, repo.ID, model.DeployKeySecret)
+	if err != nil {
+		return
+	}
+	if err := secrets.Delete(ctx, secret); err != nil {
+		log.WithError(err).Warnln("credentials: cannot delete deploy key secret")
+	}
+}
@@ -0,0 +1,90 @@
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+)
+
+type credentialInput struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+var (
+	errInvalidType    = errors.New("Invalid credential type")
+	errInvalidMachine = errors.New("Machine user credentials require a username and password")
+	errDeployKey      = errors.New("Deploy keys are not supported, since the default clone step clones over https. Use machine user credentials instead")
+)
+
+// HandlePut returns an http.HandlerFunc that processes http
+// requests to set the clone credential of the repository.
+// Deploy keys are rejected, since the default clone step clones
+// over https. A deploy key created by an earlier version is
+// revoked once the new credential is in place.
+func HandlePut(
+	creds model.CredentialStore,
+	secrets core.SecretStore,
+	gits model.GitService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx     = r.Context()
+			user, _ = request.UserFrom(ctx)
+			repo, _ = request.RepoFrom(ctx)
+		)
+		in := new(credentialInput)
+		err := json.NewDecoder(r.Body).Decode(in)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+
+		prev, _, err := creds.Find(ctx, repo.Slug)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+
+		cred := &model.Credential{
+			Slug: repo.Slug,
+			Type: in.Type,
+		}
+		switch in.Type {
+		case model.CredentialToken:
+		case model.CredentialMachine:
+			if in.Username == "" || in.Password == "" {
+				render.BadRequest(w, errInvalidMachine)
+				return
+			}
+			cred.Username = in.Username
+			cred.Password = in.Password
+		case model.CredentialDeployKey:
+			render.BadRequest(w, errDeployKey)
+			return
+		default:
+			render.BadRequest(w, errInvalidType)
+			return
+		}
+
+		err = creds.Put(ctx, cred)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if prev != nil && prev.KeyID != "" {
+			revokeKey(ctx, gits, secrets, user, repo, prev)
+		}
+		render.JSON(w, cred, 200)
+	}
+}
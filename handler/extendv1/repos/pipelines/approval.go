@@ -0,0 +1,99 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/go-chi/chi"
+)
+
+// HandleFindApproval returns an http.HandlerFunc that writes the
+// json-encoded approval policy of the pipeline for the specified
+// branch to the response body.
+func HandleFindApproval(repos core.RepositoryStore, pipelineStore model.PipelineStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx    = r.Context()
+			branch = r.FormValue("branch")
+		)
+		ref := "refs/heads/" + branch
+		if branch == "" {
+			ref = "default"
+		}
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, ".drone.yml")
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			render.NotFoundf(w, "pipeline not found")
+			return
+		}
+		render.JSON(w, pipe.Approval, 200)
+	}
+}
+
+// HandlePutApproval returns an http.HandlerFunc that processes
+// http requests to set the approval policy of the pipeline for
+// the specified branch. A null policy removes the approval gate.
+func HandlePutApproval(repos core.RepositoryStore, pipelineStore model.PipelineStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx    = r.Context()
+			branch = r.FormValue("branch")
+		)
+		var policy *model.ApprovalPolicy
+		err := json.NewDecoder(r.Body).Decode(&policy)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+		if policy != nil {
+			if err := policy.Validate(); err != nil {
+				render.BadRequest(w, err)
+				return
+			}
+		}
+
+		ref := "refs/heads/" + branch
+		if branch == "" {
+			ref = "default"
+		}
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, ".drone.yml")
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			render.NotFoundf(w, "pipeline not found")
+			return
+		}
+		pipe.Approval = policy
+		pipe.Updated = time.Now().Unix()
+		err = pipelineStore.UpdatePipeline(ctx, pipe)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, policy, 200)
+	}
+}
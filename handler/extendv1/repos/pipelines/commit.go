@@ -0,0 +1,104 @@
+package pipelines
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/go-scm/scm"
+	"github.com/go-chi/chi"
+)
+
+// HandleCommitPipeline returns an http.HandlerFunc that processes
+// http requests to commit the stored pipeline for the specified
+// slug into the repository.
+func HandleCommitPipeline(
+	repos core.RepositoryStore,
+	pipelineStore model.PipelineStore,
+	gits model.GitService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx       = r.Context()
+			name      = chi.URLParam(r, "name")
+			namespace = chi.URLParam(r, "owner")
+			branch    = r.FormValue("branch")
+			message   = r.FormValue("message")
+			user, _   = request.UserFrom(ctx)
+		)
+		repo, err := repos.FindName(ctx, namespace, name)
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
+
+		configPath := ".drone.yml"
+		ref := "refs/heads/" + branch
+		if branch == "" {
+			ref = "default"
+			branch = repo.Branch
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, configPath)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			render.NotFoundf(w, "pipeline not found")
+			return
+		}
+
+		if message == "" {
+			message = "Update " + configPath
+		}
+		params := &scm.ContentParams{
+			Branch:  branch,
+			Message: message,
+			Data:    []byte(pipe.Content),
+			Signature: scm.Signature{
+				Name:  user.Login,
+				Email: user.Email,
+			},
+		}
+
+		// the file is created if it does not yet exist in
+		// the repository, otherwise it is replaced.
+		_, res, err := gits.FindFile(ctx, user, repo.Slug, configPath, branch)
+		switch {
+		case isNotFound(res, err):
+			err = gits.CreateFile(ctx, user, repo.Slug, configPath, params)
+		case err == nil:
+			err = gits.UpdateFile(ctx, user, repo.Slug, configPath, params)
+		}
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+
+		pipe.Sync = 1
+		pipe.Updated = time.Now().Unix()
+		err = pipelineStore.UpdatePipeline(ctx, pipe)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		w.WriteHeader(204)
+	}
+}
+
+// helper function returns true if the file lookup failed
+// because the file does not exist in the repository.
+func isNotFound(res *scm.Response, err error) bool {
+	if err == scm.ErrNotFound {
+		return true
+	}
+	return err != nil && res != nil && res.Status == http.StatusNotFound
+}
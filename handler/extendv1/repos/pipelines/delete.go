@@ -0,0 +1,63 @@
+package pipelines
+
+import (
+	"net/http"
+
+	"github.com/oars-sigs/drone/handler/extendv1/audits"
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/go-chi/chi"
+)
+
+// HandleDeletePipeline returns an http.HandlerFunc that processes
+// http requests to delete the stored pipeline for the specified
+// slug and branch.
+func HandleDeletePipeline(
+	repos core.RepositoryStore,
+	pipelineStore model.PipelineStore,
+	webhook model.WebhookSender,
+	events core.Pubsub,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx       = r.Context()
+			name      = chi.URLParam(r, "name")
+			namespace = chi.URLParam(r, "owner")
+			branch    = r.FormValue("branch")
+		)
+		repo, err := repos.FindName(ctx, namespace, name)
+		if err != nil {
+			render.NotFoundf(w, "Repository not found")
+			return
+		}
+
+		ref := "refs/heads/" + branch
+		if branch == "" {
+			ref = "default"
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, ".drone.yml")
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			render.NotFoundf(w, "pipeline not found")
+			return
+		}
+		audits.SetBefore(ctx, pipe.Content)
+		audits.SetAfter(ctx, "")
+
+		err = pipelineStore.DeletePipeline(ctx, pipe)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		notify(r, webhook, events, pipelineStore, core.WebhookActionDeleted, repo, pipe)
+		w.WriteHeader(204)
+	}
+}
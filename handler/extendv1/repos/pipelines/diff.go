@@ -0,0 +1,180 @@
+package pipelines
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/diff"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/go-scm/scm"
+	"github.com/go-chi/chi"
+)
+
+// errNotFound is returned when the content to compare does
+// not exist.
+var errNotFound = errors.New("not found")
+
+// specError is returned when the spec of the content to
+// compare is invalid.
+type specError struct {
+	err error
+}
+
+func (e *specError) Error() string {
+	return e.err.Error()
+}
+
+type diffResult struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	Unified string         `json:"unified"`
+	Steps   []*diff.Change `json:"steps"`
+
+	// StepsError is set if the content cannot be parsed, for
+	// example if the pipeline is not written in yaml.
+	StepsError string `json:"steps_error,omitempty"`
+}
+
+// HandleDiff returns an http.HandlerFunc that writes the
+// json-encoded unified and step-level diff of two pipeline
+// configurations to the response body. The from and to
+// parameters select the content to compare:
+//
+//	revision:<id>   the stored pipeline revision
+//	ref:<ref>       the stored pipeline for the ref or branch
+//	repo:<branch>   the .drone.yml file in the repository, on
+//	                the default branch if empty
+func HandleDiff(
+	repos core.RepositoryStore,
+	pipelineStore model.PipelineStore,
+	gits model.GitService,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx       = r.Context()
+			name      = chi.URLParam(r, "name")
+			namespace = chi.URLParam(r, "owner")
+			from      = r.FormValue("from")
+			to        = r.FormValue("to")
+		)
+		if from == "" || to == "" {
+			render.BadRequestf(w, "The from and to parameters are required")
+			return
+		}
+		repo, err := repos.FindName(ctx, namespace, name)
+		if err != nil {
+			render.NotFoundf(w, "Repository not found")
+			return
+		}
+
+		var content [2]string
+		for i, spec := range []string{from, to} {
+			content[i], err = findContent(r, repo, pipelineStore, gits, spec)
+			if err == errNotFound {
+				render.NotFoundf(w, "%s not found", spec)
+				return
+			}
+			if _, ok := err.(*specError); ok {
+				render.BadRequestf(w, "Invalid %s: %s", spec, err)
+				return
+			}
+			if err != nil {
+				logrus.Error(err)
+				render.InternalError(w, err)
+				return
+			}
+		}
+
+		unified, err := diff.Unified(content[0], content[1], from, to)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		res := &diffResult{
+			From:    from,
+			To:      to,
+			Unified: unified,
+			Steps:   []*diff.Change{},
+		}
+		if steps, err := diff.Steps(content[0], content[1]); err != nil {
+			res.StepsError = err.Error()
+		} else {
+			res.Steps = steps
+		}
+		render.JSON(w, res, 200)
+	}
+}
+
+// helper function returns the pipeline content selected by
+// the spec.
+func findContent(
+	r *http.Request,
+	repo *core.Repository,
+	pipelineStore model.PipelineStore,
+	gits model.GitService,
+	spec string,
+) (string, error) {
+	ctx := r.Context()
+	// the pipelines are stored and committed to the .drone.yml
+	// file, so both kinds compare the same configuration file.
+	configPath := ".drone.yml"
+	kind, value := spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		kind, value = spec[:i], spec[i+1:]
+	}
+	switch kind {
+	case "revision":
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", &specError{err}
+		}
+		rev, err := pipelineStore.GetRevision(ctx, repo.ID, id)
+		if err != nil {
+			return "", err
+		}
+		if rev == nil {
+			return "", errNotFound
+		}
+		return rev.Content, nil
+	case "ref":
+		ref := value
+		if ref == "" {
+			ref = "default"
+		}
+		if ref != "default" {
+			ref = scm.ExpandRef(ref, "refs/heads")
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, configPath)
+		if err != nil {
+			return "", err
+		}
+		if !isExist {
+			return "", errNotFound
+		}
+		return pipe.Content, nil
+	case "repo":
+		branch := scm.TrimRef(value)
+		if branch == "" {
+			branch = repo.Branch
+		}
+		user, _ := request.UserFrom(ctx)
+		file, res, err := gits.FindFile(ctx, user, repo.Slug, configPath, branch)
+		if isNotFound(res, err) {
+			return "", errNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(file.Data), nil
+	}
+	return "", &specError{fmt.Errorf("unknown kind %q, expected revision, ref or repo", kind)}
+}
@@ -0,0 +1,81 @@
+package pipelines
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/go-scm/scm"
+	"github.com/go-chi/chi"
+)
+
+func TestDiff_Errors(t *testing.T) {
+	repo := &core.Repository{ID: 1, Slug: "octocat/hello-world", Branch: "master", Config: ".drone.yml"}
+	pipes := &pipelineStore{
+		revisions: map[int64]*model.Revision{1: {ID: 1, Content: "kind: pipeline\nname: default"}},
+		err:       errors.New("database is locked"),
+	}
+	handler := HandleDiff(repoStore{repo: repo}, pipes, gitService{})
+
+	tests := []struct {
+		from, to string
+		code     int
+	}{
+		{"revision:1", "revision:one", 400},
+		{"revision:1", "branch:master", 400},
+		{"revision:1", "revision:2", 404},
+		{"revision:1", "ref:master", 500},
+		{"revision:1", "repo:master", 500},
+	}
+	for _, test := range tests {
+		c := new(chi.Context)
+		c.URLParams.Add("owner", "octocat")
+		c.URLParams.Add("name", "hello-world")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/?from="+test.from+"&to="+test.to, nil)
+		r = r.WithContext(
+			context.WithValue(r.Context(), chi.RouteCtxKey, c),
+		)
+		handler(w, r)
+
+		if got, want := w.Code, test.code; got != want {
+			t.Errorf("Want response code %d for %s, got %d: %s", want, test.to, got, w.Body)
+		}
+	}
+}
+
+type repoStore struct {
+	core.RepositoryStore
+	repo *core.Repository
+}
+
+func (s repoStore) FindName(context.Context, string, string) (*core.Repository, error) {
+	return s.repo, nil
+}
+
+type pipelineStore struct {
+	model.PipelineStore
+	revisions map[int64]*model.Revision
+	err       error
+}
+
+func (s *pipelineStore) GetRevision(ctx context.Context, repoID int64, id int64) (*model.Revision, error) {
+	return s.revisions[id], nil
+}
+
+func (s *pipelineStore) GetPipeline(ctx context.Context, repo *core.Repository, ref, configPath string) (*model.Pipeline, bool, error) {
+	return nil, false, s.err
+}
+
+type gitService struct {
+	model.GitService
+}
+
+func (gitService) FindFile(ctx context.Context, user *core.User, repo, path, branch string) (*scm.Content, *scm.Response, error) {
+	return nil, &scm.Response{Status: 502}, errors.New("bad gateway")
+}
@@ -0,0 +1,115 @@
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/logger"
+	"github.com/drone/go-scm/scm"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// interval at which the client is pinged to prevent
+// reverse proxy and load balancers from closing the
+// connection.
+var pingInterval = time.Second * 30
+
+// implements a 24-hour timeout for connections. This
+// should not be necessary, but is put in place just
+// in case we encounter dangling connections.
+var timeout = time.Hour * 24
+
+// HandleEvents creates an http.HandlerFunc that streams the
+// pipeline change events of the repository to the http.Response
+// in an event stream format. If the branch or ref parameter is
+// set, only the events of the pipeline for that ref are streamed.
+func HandleEvents(
+	repos core.RepositoryStore,
+	events core.Pubsub,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			namespace = chi.URLParam(r, "owner")
+			name      = chi.URLParam(r, "name")
+			ref       = r.FormValue("ref")
+		)
+		if ref == "" && r.FormValue("branch") != "" {
+			ref = scm.ExpandRef(r.FormValue("branch"), "refs/heads")
+		}
+		logger := logger.FromRequest(r).WithFields(
+			logrus.Fields{
+				"namespace": namespace,
+				"name":      name,
+			},
+		)
+		repo, err := repos.FindName(r.Context(), namespace, name)
+		if err != nil {
+			render.NotFound(w, err)
+			logger.WithError(err).Debugln("events: cannot find repository")
+			return
+		}
+		topic := model.PipelineTopic(repo.Slug)
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("Connection", "keep-alive")
+		h.Set("X-Accel-Buffering", "no")
+
+		f, ok := w.(http.Flusher)
+		if !ok {
+			return
+		}
+
+		io.WriteString(w, ": ping\n\n")
+		f.Flush()
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		events, errc := events.Subscribe(ctx)
+		logger.Debugln("events: pipeline stream opened")
+
+	L:
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debugln("events: pipeline stream cancelled")
+				break L
+			case <-errc:
+				logger.Debugln("events: pipeline stream error")
+				break L
+			case <-time.After(pingInterval):
+				io.WriteString(w, ": ping\n\n")
+				f.Flush()
+			case event := <-events:
+				if event.Repository != topic {
+					continue
+				}
+				if ref != "" {
+					in := new(model.PipelineEvent)
+					if json.Unmarshal(event.Data, in) != nil || in.Ref != ref {
+						continue
+					}
+				}
+				io.WriteString(w, "data: ")
+				w.Write(event.Data)
+				io.WriteString(w, "\n\n")
+				f.Flush()
+			}
+		}
+
+		io.WriteString(w, "event: error\ndata: eof\n\n")
+		f.Flush()
+
+		logger.Debugln("events: pipeline stream closed")
+	}
+}
@@ -28,9 +28,13 @@ func HandleFindPipelines(
 		if branch == "" {
 			ref = "default"
 		}
+		repo, err := repos.FindName(ctx, namespace, name)
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
 		configPath := ".drone.yml"
-		slug := namespace + "/" + name
-		pipe, isExist, err := pipelineStore.GetPipeline(ctx, slug, ref, configPath)
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, configPath)
 		if err != nil {
 			logrus.Error(err)
 			render.InternalError(w, err)
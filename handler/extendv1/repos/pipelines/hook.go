@@ -0,0 +1,71 @@
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/drone/logger"
+)
+
+// helper function sends the pipeline event to the webhook
+// endpoints and publishes it to the pipeline event stream of
+// the repository. Errors are logged, since the pipeline is
+// already saved.
+func notify(
+	r *http.Request,
+	webhook model.WebhookSender,
+	events core.Pubsub,
+	pipelineStore model.PipelineStore,
+	action string,
+	repo *core.Repository,
+	pipe *model.Pipeline,
+) {
+	ctx := r.Context()
+	user, _ := request.UserFrom(ctx)
+	payload := &model.PipelineHook{
+		User:       user,
+		Repo:       repo,
+		Ref:        pipe.Ref,
+		ConfigPath: pipe.ConfigPath,
+		Template:   pipe.Template,
+	}
+	rev, err := pipelineStore.FindRevision(ctx, repo.ID, pipe.Ref, time.Now().Unix())
+	if err == nil && rev != nil && rev.Ref == pipe.Ref {
+		payload.Revision = rev.ID
+	}
+	// the webhook is sent in the background with a detached
+	// context, so slow endpoints do not delay the response.
+	log := logger.FromRequest(r)
+	go func() {
+		err := webhook.SendEvent(context.Background(), model.WebhookEventPipeline, action, payload)
+		if err != nil {
+			log.WithError(err).Warnln("api: cannot send pipeline webhook")
+		}
+	}()
+
+	event := &model.PipelineEvent{
+		Action:     action,
+		Slug:       repo.Slug,
+		Ref:        pipe.Ref,
+		ConfigPath: pipe.ConfigPath,
+		Revision:   payload.Revision,
+		Updated:    time.Now().Unix(),
+	}
+	if user != nil {
+		event.User = user.Login
+	}
+	data, _ := json.Marshal(event)
+	err = events.Publish(ctx, &core.Message{
+		Repository: model.PipelineTopic(repo.Slug),
+		Data:       data,
+	})
+	if err != nil {
+		log.WithError(err).Warnln("api: cannot publish pipeline event")
+	}
+}
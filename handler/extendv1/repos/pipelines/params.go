@@ -0,0 +1,98 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/sirupsen/logrus"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/go-scm/scm"
+	"github.com/go-chi/chi"
+)
+
+// HandleFindParams returns an http.HandlerFunc that writes the
+// json-encoded build parameter schema of the pipeline for the
+// specified ref (or branch) to the response body.
+func HandleFindParams(repos core.RepositoryStore, pipelineStore model.PipelineStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+			ref = r.FormValue("ref")
+		)
+		if ref == "" && r.FormValue("branch") != "" {
+			ref = scm.ExpandRef(r.FormValue("branch"), "refs/heads")
+		}
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
+		pipe, err := pipelineStore.FindPipeline(ctx, repo, ref)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		params := []*model.Param{}
+		if pipe != nil && pipe.Params != nil {
+			params = pipe.Params
+		}
+		render.JSON(w, params, 200)
+	}
+}
+
+// HandlePutParams returns an http.HandlerFunc that processes
+// http requests to declare the build parameters of the pipeline
+// for the specified branch.
+func HandlePutParams(repos core.RepositoryStore, pipelineStore model.PipelineStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx    = r.Context()
+			branch = r.FormValue("branch")
+		)
+		params := []*model.Param{}
+		err := json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+		err = model.ValidateParams(params)
+		if err != nil {
+			render.BadRequest(w, err)
+			return
+		}
+
+		ref := "refs/heads/" + branch
+		if branch == "" {
+			ref = "default"
+		}
+		repo, err := repos.FindName(ctx, chi.URLParam(r, "owner"), chi.URLParam(r, "name"))
+		if err != nil {
+			render.NotFound(w, err)
+			return
+		}
+		pipe, isExist, err := pipelineStore.GetPipeline(ctx, repo, ref, ".drone.yml")
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		if !isExist {
+			render.NotFoundf(w, "pipeline not found")
+			return
+		}
+		pipe.Params = params
+		pipe.Updated = time.Now().Unix()
+		err = pipelineStore.UpdatePipeline(ctx, pipe)
+		if err != nil {
+			logrus.Error(err)
+			render.InternalError(w, err)
+			return
+		}
+		render.JSON(w, params, 200)
+	}
+}
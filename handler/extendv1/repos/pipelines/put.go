@@ -9,7 +9,9 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/oars-sigs/drone/handler/extendv1/audits"
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/leak"
 
 	"github.com/drone/drone/core"
 	"github.com/drone/drone/handler/api/render"
@@ -17,10 +19,18 @@ import (
 )
 
 // HandlePutPipeline returns an http.HandlerFunc that processes http
-// requests to put a pipeline for the specified slug.
+// requests to put a pipeline for the specified slug. The template
+// the pipeline was derived from, if any, is recorded. Content with
+// likely secrets is rejected, or saved and the findings returned,
+// depending on the leak detection mode. A pipeline webhook event
+// is sent, and the pipeline event published, once the pipeline
+// is saved.
 func HandlePutPipeline(
 	repos core.RepositoryStore,
 	pipelineStore model.PipelineStore,
+	leaks *leak.Detector,
+	webhook model.WebhookSender,
+	events core.Pubsub,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -28,6 +38,7 @@ func HandlePutPipeline(
 			name      = chi.URLParam(r, "name")
 			namespace = chi.URLParam(r, "owner")
 			branch    = r.FormValue("branch")
+			template  = r.FormValue("template")
 		)
 
 		in, err := ioutil.ReadAll(r.Body)
@@ -35,44 +46,52 @@ func HandlePutPipeline(
 			render.BadRequest(w, err)
 			return
 		}
+		found := leaks.Check(string(in))
+		if found != nil && leaks.Reject() {
+			render.JSON(w, found, 422)
+			return
+		}
 		configPath := ".drone.yml"
 		ref := "refs/heads/" + branch
 		if branch == "" {
 			ref = "default"
 		}
-		slug := namespace + "/" + name
-		pipe, isExist, err := pipelineStore.GetPipeline(ctx, slug, ref, configPath)
+		repo, err := repos.FindName(ctx, namespace, name)
 		if err != nil {
-			logrus.Error(err)
-			render.InternalError(w, err)
+			render.NotFoundf(w, "Repository not found")
 			return
 		}
-		if !isExist {
-			pipe := &model.Pipeline{
-				UUID:       uuid.New().String(),
-				Slug:       slug,
-				Ref:        ref,
-				ConfigPath: configPath,
-				Content:    string(in),
-				Created:    time.Now().Unix(),
-				Updated:    time.Now().Unix(),
-			}
-			err = pipelineStore.CreatePipeline(ctx, pipe)
-			if err != nil {
-				logrus.Error(err)
-				render.InternalError(w, err)
-				return
-			}
-			w.WriteHeader(204)
+		action := core.WebhookActionCreated
+		if prev, ok, err := pipelineStore.GetPipeline(ctx, repo, ref, configPath); err == nil && ok {
+			audits.SetBefore(ctx, prev.Content)
+			action = core.WebhookActionUpdated
 		}
-		pipe.Content = string(in)
-		pipe.Updated = time.Now().Unix()
-		err = pipelineStore.UpdatePipeline(ctx, pipe)
+		now := time.Now().Unix()
+		pipe := &model.Pipeline{
+			UUID:       uuid.New().String(),
+			Slug:       repo.Slug,
+			RepoID:     repo.ID,
+			RepoUID:    repo.UID,
+			Ref:        ref,
+			ConfigPath: configPath,
+			Content:    string(in),
+			Template:   template,
+			Created:    now,
+			Updated:    now,
+		}
+		// the pipeline is created, or the content of the existing
+		// pipeline replaced, in a single atomic upsert.
+		err = pipelineStore.SavePipeline(ctx, pipe)
 		if err != nil {
-			logrus.Error("update", err)
+			logrus.Error(err)
 			render.InternalError(w, err)
 			return
 		}
+		notify(r, webhook, events, pipelineStore, action, repo, pipe)
+		if found != nil {
+			render.JSON(w, found, 200)
+			return
+		}
 		w.WriteHeader(204)
 	}
 }
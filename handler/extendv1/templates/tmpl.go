@@ -1,13 +1,19 @@
 package templates
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 
+	"github.com/drone/drone/core"
 	"github.com/drone/drone/handler/api/render"
+	"github.com/drone/drone/handler/api/request"
+	"github.com/drone/drone/logger"
 	"github.com/go-chi/chi"
+	"github.com/oars-sigs/drone/handler/extendv1/audits"
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/leak"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,9 +50,13 @@ func HandleGetTemp(
 }
 
 // HandleCreateTemp returns an http.HandlerFunc that processes http
-// requests to create the templates.
+// requests to create the templates. Content with likely secrets
+// is rejected, or saved and the findings returned, depending on
+// the leak detection mode.
 func HandleCreateTemp(
 	tmpls model.TemplateStore,
+	leaks *leak.Detector,
+	webhook model.WebhookSender,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -66,20 +76,33 @@ func HandleCreateTemp(
 			return
 		}
 
+		found := leaks.Check(tmps.Content)
+		if found != nil && leaks.Reject() {
+			render.JSON(w, found, 422)
+			return
+		}
+
+		audits.SetAfter(ctx, tmps.Content)
+
 		err = tmpls.CreateTemplate(ctx, &tmps)
 
 		if err != nil {
 			render.InternalError(w, err)
 			return
 		}
-		render.JSON(w, nil, 200)
+		sendHook(r, webhook, core.WebhookActionCreated, &tmps)
+		render.JSON(w, found, 200)
 	}
 }
 
 // HandlePutTemp returns an http.HandlerFunc that processes http
-// requests to modify the templates.
+// requests to modify the templates. Content with likely secrets
+// is rejected, or saved and the findings returned, depending on
+// the leak detection mode.
 func HandlePutTemp(
 	tmpls model.TemplateStore,
+	leaks *leak.Detector,
+	webhook model.WebhookSender,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -99,13 +122,25 @@ func HandlePutTemp(
 			return
 		}
 
+		found := leaks.Check(tmps.Content)
+		if found != nil && leaks.Reject() {
+			render.JSON(w, found, 422)
+			return
+		}
+
+		if prev, _, err := tmpls.FindTemplate(ctx, tmps.UUID); err == nil {
+			audits.SetBefore(ctx, prev.Content)
+		}
+		audits.SetAfter(ctx, tmps.Content)
+
 		err = tmpls.PutTemplate(ctx, &tmps)
 
 		if err != nil {
 			render.InternalError(w, err)
 			return
 		}
-		render.JSON(w, nil, 200)
+		sendHook(r, webhook, core.WebhookActionUpdated, &tmps)
+		render.JSON(w, found, 200)
 	}
 }
 
@@ -113,6 +148,7 @@ func HandlePutTemp(
 // requests to delete the templates.
 func HandleDeleteTemp(
 	tmpls model.TemplateStore,
+	webhook model.WebhookSender,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -120,11 +156,19 @@ func HandleDeleteTemp(
 			uuid = chi.URLParam(r, "uuid")
 		)
 
-		err := tmpls.DeleteTemplate(ctx, uuid)
+		prev, _, err := tmpls.FindTemplate(ctx, uuid)
+		if err == nil {
+			audits.SetBefore(ctx, prev.Content)
+		}
+		audits.SetAfter(ctx, "")
+
+		err = tmpls.DeleteTemplate(ctx, uuid)
 
 		if err != nil {
 			render.InternalError(w, err)
+			return
 		}
+		sendHook(r, webhook, core.WebhookActionDeleted, prev)
 		render.JSON(w, nil, 200)
 	}
 }
@@ -155,3 +199,24 @@ func HandleFindTemp(
 		render.JSON(w, res, 200)
 	}
 }
+
+// helper function sends the template event to the webhook
+// endpoints in the background with a detached context. Errors
+// are logged, since the template is already saved.
+func sendHook(r *http.Request, webhook model.WebhookSender, action string, tmpl *model.Template) {
+	user, _ := request.UserFrom(r.Context())
+	payload := &model.TemplateHook{
+		User:   user,
+		UUID:   tmpl.UUID,
+		Name:   tmpl.Name,
+		Format: tmpl.Format,
+		Type:   tmpl.Type,
+	}
+	log := logger.FromRequest(r)
+	go func() {
+		err := webhook.SendEvent(context.Background(), model.WebhookEventTemplate, action, payload)
+		if err != nil {
+			log.WithError(err).Warnln("api: cannot send template webhook")
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"fmt"
+)
+
+// ApprovalPolicy requires builds of a pipeline to be approved
+// before they run.
+type ApprovalPolicy struct {
+	// Approvers is the list of user logins allowed to approve
+	// builds. If empty, repository admins can approve.
+	Approvers []string `json:"approvers"`
+
+	// MinApprovals is the number of approvals required.
+	MinApprovals int `json:"min_approvals"`
+
+	// Events is the list of build events that require approval.
+	// If empty, all events require approval.
+	Events []string `json:"events"`
+}
+
+// Validate returns an error if the approval policy is invalid.
+func (p *ApprovalPolicy) Validate() error {
+	if p.MinApprovals < 1 {
+		return fmt.Errorf("at least one approval is required")
+	}
+	if len(p.Approvers) != 0 && p.MinApprovals > len(p.Approvers) {
+		return fmt.Errorf("%d approvals required, but only %d approvers", p.MinApprovals, len(p.Approvers))
+	}
+	return nil
+}
+
+// Match returns true if builds of the event require approval.
+func (p *ApprovalPolicy) Match(event string) bool {
+	if len(p.Events) == 0 {
+		return true
+	}
+	for _, e := range p.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApprover returns true if the user login is in the list
+// of approvers.
+func (p *ApprovalPolicy) IsApprover(login string) bool {
+	for _, approver := range p.Approvers {
+		if approver == login {
+			return true
+		}
+	}
+	return false
+}
+
+// Approval records the approval of a blocked build.
+type Approval struct {
+	BuildID int64  `json:"build_id"`
+	Slug    string `json:"slug"`
+	Number  int64  `json:"number"`
+	User    string `json:"user"`
+	Created int64  `json:"created"`
+}
+
+type ApprovalStore interface {
+	//List returns the approvals of the build.
+	List(ctx context.Context, buildID int64) ([]*Approval, error)
+
+	//Create persists a new approval to the datastore.
+	Create(ctx context.Context, approval *Approval) error
+}
@@ -0,0 +1,45 @@
+package model
+
+import "context"
+
+// WebhookEventAudit is the webhook event of the audit log
+// entries.
+const WebhookEventAudit = "audit"
+
+// Audit records a mutating request to the extend api.
+type Audit struct {
+	ID     int64  `json:"id"`
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+
+	// Before and After are the digests of the target content
+	// before and after the change, if known.
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+
+	SourceIP string `json:"source_ip"`
+	Status   int    `json:"status"`
+	Created  int64  `json:"created"`
+}
+
+// AuditFilter filters the audit log. Empty fields match all
+// entries. Target matches entries with the target prefix.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  int64
+	Until  int64
+	Limit  int
+	Offset int
+}
+
+type AuditStore interface {
+	//List returns the audit log entries matching the filter,
+	//most recent first.
+	List(ctx context.Context, filter AuditFilter) ([]*Audit, error)
+
+	//Create persists a new audit log entry to the datastore.
+	Create(ctx context.Context, audit *Audit) error
+}
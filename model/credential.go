@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+)
+
+// Clone credential types.
+const (
+	// CredentialToken clones with the oauth token of the
+	// user that triggered the build. This is the default.
+	CredentialToken = "token"
+
+	// CredentialMachine clones with a machine user login
+	// and password configured for the repository.
+	CredentialMachine = "machine"
+
+	// CredentialDeployKey is no longer accepted, since the
+	// default clone step clones over https. Deploy keys created
+	// by an earlier version are revoked when the credential is
+	// replaced or reset, and are cloned with the user token.
+	CredentialDeployKey = "deploy_key"
+)
+
+// DeployKeySecret is the name of the repository secret that
+// holds the private deploy key.
+const DeployKeySecret = "deploy_key"
+
+type Credential struct {
+	Slug      string `json:"slug"`
+	Type      string `json:"type"`
+	Username  string `json:"username"`
+	Password  string `json:"-"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Created   int64  `json:"created"`
+	Updated   int64  `json:"updated"`
+}
+
+type CredentialStore interface {
+	//Find returns the clone credential of the repository.
+	Find(ctx context.Context, slug string) (*Credential, bool, error)
+
+	//Put persists the clone credential of the repository.
+	Put(ctx context.Context, cred *Credential) error
+
+	//Delete removes the clone credential of the repository.
+	Delete(ctx context.Context, slug string) error
+}
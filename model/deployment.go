@@ -0,0 +1,15 @@
+package model
+
+import (
+	"context"
+
+	"github.com/drone/drone/core"
+)
+
+type DeploymentStore interface {
+	//List returns the promote and rollback builds of the
+	//repository to the target environment, most recent first.
+	//If the target is empty, deployments to all environments
+	//are returned.
+	List(ctx context.Context, repoID int64, target string) ([]*core.Build, error)
+}
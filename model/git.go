@@ -24,4 +24,14 @@ type GitService interface {
 
 	//
 	FindTags(ctx context.Context, user *core.User, repo string) ([]*scm.Reference, error)
+
+	// FindPullRequest returns the pull request by number.
+	FindPullRequest(ctx context.Context, user *core.User, repo string, number int) (*scm.PullRequest, error)
+
+	// CreateDeployKey adds a read-only deploy key to the
+	// repository and returns the key id.
+	CreateDeployKey(ctx context.Context, user *core.User, repo, title, key string) (string, error)
+
+	// DeleteDeployKey removes the deploy key from the repository.
+	DeleteDeployKey(ctx context.Context, user *core.User, repo, id string) error
 }
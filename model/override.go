@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+
+	"github.com/drone/drone/core"
+)
+
+// Override is a pipeline configuration submitted for a
+// single build.
+type Override struct {
+	UUID    string `json:"uuid"`
+	Slug    string `json:"slug"`
+	BuildID int64  `json:"build_id"`
+	Number  int64  `json:"number"`
+	Content string `json:"content"`
+	Creator string `json:"creator"`
+	Created int64  `json:"created"`
+}
+
+type OverrideStore interface {
+	//Find returns the pipeline override of the build being
+	//triggered, or of the build it promotes or rolls back, or
+	//nil if the build has no override.
+	Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error)
+
+	//GetOverride returns the pipeline override of the build
+	//from the datastore.
+	GetOverride(ctx context.Context, buildID int64) (*Override, bool, error)
+
+	//CreateOverride persists a new pipeline override to the datastore.
+	CreateOverride(ctx context.Context, override *Override) error
+
+	//LinkOverride links the pipeline override to the build it
+	//was triggered for.
+	LinkOverride(ctx context.Context, override *Override) error
+}
+
+type overrideKey struct{}
+
+// WithOverride returns a copy of the context with the pipeline
+// override of the build being triggered. The override is only
+// passed to the triggerer, so build parameters cannot reference
+// it.
+func WithOverride(ctx context.Context, override *Override) context.Context {
+	return context.WithValue(ctx, overrideKey{}, override)
+}
+
+// OverrideFrom returns the pipeline override of the build
+// being triggered.
+func OverrideFrom(ctx context.Context) (*Override, bool) {
+	override, ok := ctx.Value(overrideKey{}).(*Override)
+	return override, ok
+}
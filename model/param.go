@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Build parameter types.
+const (
+	ParamString  = "string"
+	ParamNumber  = "number"
+	ParamBoolean = "boolean"
+	ParamChoice  = "choice"
+)
+
+// Param declares a parameter of manually triggered builds.
+type Param struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Required    bool     `json:"required"`
+	Choices     []string `json:"choices,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Validate returns an error if the parameter declaration
+// is invalid.
+func (p *Param) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("parameter name is required")
+	}
+	switch p.Type {
+	case ParamString, ParamNumber, ParamBoolean:
+	case ParamChoice:
+		if len(p.Choices) == 0 {
+			return fmt.Errorf("parameter %s: choices are required", p.Name)
+		}
+	default:
+		return fmt.Errorf("parameter %s: invalid type %q", p.Name, p.Type)
+	}
+	if p.Default != "" {
+		return p.Check(p.Default)
+	}
+	return nil
+}
+
+// Check returns an error if the value does not match the
+// parameter type.
+func (p *Param) Check(value string) error {
+	switch p.Type {
+	case ParamNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("parameter %s: %q is not a number", p.Name, value)
+		}
+	case ParamBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("parameter %s: %q is not a boolean", p.Name, value)
+		}
+	case ParamChoice:
+		for _, choice := range p.Choices {
+			if choice == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("parameter %s: %q is not one of %v", p.Name, value, p.Choices)
+	}
+	return nil
+}
+
+// ValidateParams returns an error if the parameter
+// declarations are invalid or declare a name twice.
+func ValidateParams(params []*Param) error {
+	names := map[string]struct{}{}
+	for _, param := range params {
+		if err := param.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[param.Name]; ok {
+			return fmt.Errorf("parameter %s is declared twice", param.Name)
+		}
+		names[param.Name] = struct{}{}
+	}
+	return nil
+}
+
+// ResolveParams validates the submitted values against the
+// parameter declarations and returns the values with the
+// defaults applied. Undeclared values are rejected.
+func ResolveParams(params []*Param, values map[string]string) (map[string]string, error) {
+	declared := map[string]*Param{}
+	for _, param := range params {
+		declared[param.Name] = param
+	}
+	for name := range values {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("parameter %s is not declared", name)
+		}
+	}
+	out := map[string]string{}
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" {
+			if param.Required {
+				return nil, fmt.Errorf("parameter %s is required", param.Name)
+			}
+			continue
+		}
+		if err := param.Check(value); err != nil {
+			return nil, err
+		}
+		out[param.Name] = value
+	}
+	return out, nil
+}
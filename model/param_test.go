@@ -0,0 +1,50 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveParams(t *testing.T) {
+	params := []*Param{
+		{Name: "env", Type: ParamChoice, Choices: []string{"dev", "prod"}, Required: true},
+		{Name: "replicas", Type: ParamNumber, Default: "1"},
+		{Name: "debug", Type: ParamBoolean},
+	}
+	got, err := ResolveParams(params, map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"env": "prod", "replicas": "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Want params %v, got %v", want, got)
+	}
+
+	tests := []map[string]string{
+		{},                              // required parameter missing
+		{"env": "test"},                 // invalid choice
+		{"env": "dev", "replicas": "x"}, // invalid number
+		{"env": "dev", "debug": "yes"},  // invalid boolean
+		{"env": "dev", "envv": "dev"},   // undeclared parameter
+	}
+	for _, values := range tests {
+		if _, err := ResolveParams(params, values); err == nil {
+			t.Errorf("Expect error resolving %v", values)
+		}
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	tests := [][]*Param{
+		{{Name: "", Type: ParamString}},
+		{{Name: "env", Type: "list"}},
+		{{Name: "env", Type: ParamChoice}},
+		{{Name: "replicas", Type: ParamNumber, Default: "one"}},
+		{{Name: "env", Type: ParamString}, {Name: "env", Type: ParamString}},
+	}
+	for _, params := range tests {
+		if err := ValidateParams(params); err == nil {
+			t.Errorf("Expect error validating %v", params[0])
+		}
+	}
+}
@@ -7,25 +7,68 @@ import (
 )
 
 type Pipeline struct {
-	UUID       string `json:"uuid"`
-	Name       string `json:"name"`
-	Repo       string `json:"repo"`
-	Slug       string `json:"slug"`
-	Ref        string `json:"ref"`
-	ConfigPath string `json:"config_path"`
-	Content    string `json:"content"`
-	Sync       int    `json:"sync"`
-	Created    int64  `json:"created"`
-	Updated    int64  `json:"updated"`
+	UUID       string          `json:"uuid"`
+	Name       string          `json:"name"`
+	Repo       string          `json:"repo"`
+	Slug       string          `json:"slug"`
+	RepoID     int64           `json:"repo_id"`
+	RepoUID    string          `json:"repo_uid"`
+	Ref        string          `json:"ref"`
+	ConfigPath string          `json:"config_path"`
+	Content    string          `json:"content"`
+	Params     []*Param        `json:"params"`
+	Template   string          `json:"template"`
+	Approval   *ApprovalPolicy `json:"approval"`
+	Sync       int             `json:"sync"`
+	Created    int64           `json:"created"`
+	Updated    int64           `json:"updated"`
 }
 
 type PipelineStore interface {
 	Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error)
-	GetPipeline(ctx context.Context, slug, ref, configPath string) (*Pipeline, bool, error)
+	GetPipeline(ctx context.Context, repo *core.Repository, ref, configPath string) (*Pipeline, bool, error)
+	FindPipeline(ctx context.Context, repo *core.Repository, ref string) (*Pipeline, error)
 	UpdatePipeline(ctx context.Context, pipe *Pipeline) error
 	CreatePipeline(ctx context.Context, pipe *Pipeline) error
+	SavePipeline(ctx context.Context, pipe *Pipeline) error
+	DeletePipeline(ctx context.Context, pipe *Pipeline) error
+	ListByTemplate(ctx context.Context, template string) ([]*Pipeline, error)
+	FindRevision(ctx context.Context, repoID int64, ref string, before int64) (*Revision, error)
+	GetRevision(ctx context.Context, repoID int64, id int64) (*Revision, error)
 }
 
 type PipelineService interface {
 	Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error)
 }
+
+// Revision is a snapshot of the pipeline content, recorded
+// each time the content of the pipeline changes.
+type Revision struct {
+	ID      int64  `json:"id"`
+	RepoID  int64  `json:"repo_id"`
+	Slug    string `json:"slug"`
+	Ref     string `json:"ref"`
+	Content string `json:"content"`
+	Created int64  `json:"created"`
+}
+
+// PipelineEvent notifies the pipeline editor that a stored
+// pipeline changed, so it can refresh the pipeline or warn
+// about concurrent edits.
+type PipelineEvent struct {
+	Action     string `json:"action"`
+	Slug       string `json:"slug"`
+	Ref        string `json:"ref"`
+	ConfigPath string `json:"config_path"`
+	Revision   int64  `json:"revision,omitempty"`
+	User       string `json:"user,omitempty"`
+	Updated    int64  `json:"updated"`
+}
+
+// PipelineTopic returns the pubsub topic of the pipeline
+// events of the repository. The topic never matches a
+// repository slug, so the events are not sent to the drone
+// build event streams.
+func PipelineTopic(slug string) string {
+	return "pipeline:" + slug
+}
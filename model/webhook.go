@@ -0,0 +1,49 @@
+package model
+
+import (
+	"context"
+
+	"github.com/drone/drone/core"
+)
+
+// Webhook event types of the extend api, in addition to the
+// audit event. The actions are the drone webhook actions, such
+// as created, updated and deleted.
+const (
+	WebhookEventPipeline = "pipeline"
+	WebhookEventTemplate = "template"
+)
+
+// PipelineHook is the payload of the pipeline events.
+type PipelineHook struct {
+	User       *core.User       `json:"user,omitempty"`
+	Repo       *core.Repository `json:"repo"`
+	Ref        string           `json:"ref"`
+	ConfigPath string           `json:"config_path"`
+	Template   string           `json:"template,omitempty"`
+
+	// Revision is the id of the pipeline revision with the
+	// current content, or of the last revision if the pipeline
+	// was deleted.
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// TemplateHook is the payload of the template events. The
+// template content is not included.
+type TemplateHook struct {
+	User   *core.User `json:"user,omitempty"`
+	UUID   string     `json:"uuid"`
+	Name   string     `json:"name"`
+	Format string     `json:"format"`
+	Type   string     `json:"type"`
+}
+
+// WebhookSender sends the drone webhooks, and the webhooks of
+// the extend api events, to the global endpoints.
+type WebhookSender interface {
+	core.WebhookSender
+
+	// SendEvent sends the event with the payload data to the
+	// global endpoints.
+	SendEvent(ctx context.Context, event, action string, data interface{}) error
+}
@@ -0,0 +1,153 @@
+// Package diff compares pipeline configurations, as a unified
+// text diff and as a semantic diff of the pipeline steps.
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/drone/drone-yaml/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Change types.
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Modified = "modified"
+)
+
+// Change is a change to a pipeline or a pipeline step. The step
+// is empty if the pipeline was added or removed.
+type Change struct {
+	Pipeline string   `json:"pipeline"`
+	Step     string   `json:"step,omitempty"`
+	Change   string   `json:"change"`
+	Fields   []string `json:"fields,omitempty"`
+}
+
+// Unified returns the unified diff of the content, with three
+// lines of context.
+func Unified(from, to, fromName, toName string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// Steps returns the changes to the steps of the pipelines in
+// the yaml content. Pipelines and steps are matched by name;
+// a modified step lists the names of the changed fields.
+func Steps(from, to string) ([]*Change, error) {
+	prev, err := pipelines(from)
+	if err != nil {
+		return nil, err
+	}
+	next, err := pipelines(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []*Change{}
+	for _, pipeline := range next.names {
+		before, ok := prev.byName[pipeline]
+		if !ok {
+			changes = append(changes, &Change{Pipeline: pipeline, Change: Added})
+			continue
+		}
+		changes = append(changes, steps(pipeline, before, next.byName[pipeline])...)
+	}
+	for _, pipeline := range prev.names {
+		if _, ok := next.byName[pipeline]; !ok {
+			changes = append(changes, &Change{Pipeline: pipeline, Change: Removed})
+		}
+	}
+	return changes, nil
+}
+
+// helper function returns the changes to the steps of the
+// pipeline, in the order of the steps.
+func steps(pipeline string, prev, next *yaml.Pipeline) []*Change {
+	before := map[string]*yaml.Container{}
+	for _, step := range prev.Steps {
+		before[step.Name] = step
+	}
+	after := map[string]struct{}{}
+
+	var changes []*Change
+	for _, step := range next.Steps {
+		after[step.Name] = struct{}{}
+		old, ok := before[step.Name]
+		if !ok {
+			changes = append(changes, &Change{Pipeline: pipeline, Step: step.Name, Change: Added})
+			continue
+		}
+		if fields := fields(old, step); len(fields) != 0 {
+			changes = append(changes, &Change{Pipeline: pipeline, Step: step.Name, Change: Modified, Fields: fields})
+		}
+	}
+	for _, step := range prev.Steps {
+		if _, ok := after[step.Name]; !ok {
+			changes = append(changes, &Change{Pipeline: pipeline, Step: step.Name, Change: Removed})
+		}
+	}
+	return changes
+}
+
+// helper function returns the sorted names of the fields that
+// differ between the steps.
+func fields(prev, next *yaml.Container) []string {
+	a, b := toMap(prev), toMap(next)
+	var out []string
+	for key, value := range a {
+		if !reflect.DeepEqual(value, b[key]) {
+			out = append(out, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	raw, _ := json.Marshal(v)
+	json.Unmarshal(raw, &out)
+	return out
+}
+
+type pipelineSet struct {
+	names  []string
+	byName map[string]*yaml.Pipeline
+}
+
+// helper function parses the pipelines in the yaml content.
+// Empty content has no pipelines.
+func pipelines(content string) (*pipelineSet, error) {
+	set := &pipelineSet{byName: map[string]*yaml.Pipeline{}}
+	if strings.TrimSpace(content) == "" {
+		return set, nil
+	}
+	manifest, err := yaml.ParseString(content)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range manifest.Resources {
+		pipeline, ok := resource.(*yaml.Pipeline)
+		if !ok {
+			continue
+		}
+		set.names = append(set.names, pipeline.Name)
+		set.byName[pipeline.Name] = pipeline
+	}
+	return set, nil
+}
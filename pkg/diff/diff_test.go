@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+const before = `
+kind: pipeline
+name: default
+
+steps:
+- name: test
+  image: golang:1.14
+  commands:
+  - go test ./...
+- name: publish
+  image: plugins/docker
+`
+
+const after = `
+kind: pipeline
+name: default
+
+steps:
+- name: test
+  image: golang:1.15
+  commands:
+  - go test ./...
+- name: notify
+  image: plugins/slack
+---
+kind: pipeline
+name: release
+`
+
+func TestSteps(t *testing.T) {
+	changes, err := Steps(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Pipeline: "default", Step: "test", Change: Modified, Fields: []string{"image"}},
+		{Pipeline: "default", Step: "notify", Change: Added},
+		{Pipeline: "default", Step: "publish", Change: Removed},
+		{Pipeline: "release", Change: Added},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Want %d changes, got %d", len(want), len(changes))
+	}
+	for i, change := range changes {
+		if change.Pipeline != want[i].Pipeline || change.Step != want[i].Step ||
+			change.Change != want[i].Change || strings.Join(change.Fields, ",") != strings.Join(want[i].Fields, ",") {
+			t.Errorf("Want change %v, got %v", want[i], *change)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	out, err := Unified(before, after, "ref:default", "repo:master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"--- ref:default", "+++ repo:master", "-  image: golang:1.14", "+  image: golang:1.15"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Want unified diff to contain %q", line)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package leak detects likely secrets in pipeline and template
+// content, such as private keys, access tokens and other high
+// entropy strings.
+package leak
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Mode defines how content with likely secrets is handled.
+type Mode string
+
+// Mode enumeration.
+const (
+	ModeOff    Mode = "off"
+	ModeWarn   Mode = "warn"
+	ModeReject Mode = "reject"
+)
+
+// Message and Suggestion are returned with the findings.
+const (
+	Message    = "content contains likely secrets"
+	Suggestion = "Move the secret values to repository secrets and reference them with from_secret."
+)
+
+// Finding is a likely secret found in the content.
+type Finding struct {
+	Line        int    `json:"line"`
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Match       string `json:"match"`
+}
+
+// Result is the result of a content scan.
+type Result struct {
+	Message    string     `json:"message"`
+	Findings   []*Finding `json:"findings"`
+	Suggestion string     `json:"suggestion"`
+}
+
+// Detector scans content for likely secrets.
+type Detector struct {
+	Mode Mode
+}
+
+// New returns a new Detector. An unknown mode is treated as
+// warn.
+func New(mode string) *Detector {
+	switch Mode(mode) {
+	case ModeOff, ModeReject:
+		return &Detector{Mode: Mode(mode)}
+	default:
+		return &Detector{Mode: ModeWarn}
+	}
+}
+
+// Check scans the content. It returns nil if detection is
+// disabled or no likely secrets are found.
+func (d *Detector) Check(content string) *Result {
+	if d == nil || d.Mode == ModeOff {
+		return nil
+	}
+	findings := Scan(content)
+	if len(findings) == 0 {
+		return nil
+	}
+	return &Result{
+		Message:    Message,
+		Findings:   findings,
+		Suggestion: Suggestion,
+	}
+}
+
+// Reject returns true if content with likely secrets is
+// rejected.
+func (d *Detector) Reject() bool {
+	return d != nil && d.Mode == ModeReject
+}
+
+type rule struct {
+	name        string
+	description string
+	pattern     *regexp.Regexp
+	// group of the pattern that holds the secret value.
+	group int
+	// minimum entropy of the secret value, if any.
+	entropy float64
+}
+
+var rules = []*rule{
+	{
+		name:        "private-key",
+		description: "Private key",
+		pattern:     regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		name:        "aws-access-key-id",
+		description: "AWS access key ID",
+		pattern:     regexp.MustCompile(`\b((AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA)[0-9A-Z]{16})\b`),
+		group:       1,
+	},
+	{
+		name:        "aws-secret-access-key",
+		description: "AWS secret access key",
+		pattern:     regexp.MustCompile(`(?i)aws.{0,20}?(secret|key).{0,20}?[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		group:       2,
+	},
+	{
+		name:        "gitee-token",
+		description: "Gitee access token",
+		pattern:     regexp.MustCompile(`(?i)gitee.{0,20}?[:=]\s*["']?([0-9a-f]{32})\b`),
+		group:       1,
+	},
+	{
+		name:        "github-token",
+		description: "GitHub token",
+		pattern:     regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255})\b`),
+		group:       1,
+	},
+	{
+		name:        "hardcoded-credential",
+		description: "Hardcoded credential",
+		pattern:     regexp.MustCompile(`(?i)\b[\w.-]*(password|passwd|secret|token|api_?key|access_?key|credential)[\w.-]*["']?\s*[:=]\s*["']?([^\s"'#]{8,})`),
+		group:       2,
+		entropy:     3,
+	},
+	{
+		name:        "high-entropy-string",
+		description: "High entropy string",
+		pattern:     regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`),
+		entropy:     4.5,
+	},
+}
+
+// Scan scans the content for likely secrets and returns one
+// finding per line.
+func Scan(content string) []*Finding {
+	var findings []*Finding
+	for i, line := range strings.Split(content, "\n") {
+		if finding := scanLine(line); finding != nil {
+			finding.Line = i + 1
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// helper function returns the first finding in the line.
+func scanLine(line string) *Finding {
+	for _, rule := range rules {
+		for _, match := range rule.pattern.FindAllStringSubmatch(line, -1) {
+			value := match[rule.group]
+			if isReference(match[0], value) {
+				continue
+			}
+			if rule.entropy != 0 && entropy(value) < rule.entropy {
+				continue
+			}
+			return &Finding{
+				Rule:        rule.name,
+				Description: rule.description,
+				Match:       redact(value),
+			}
+		}
+	}
+	return nil
+}
+
+// helper function returns true if the value references a
+// variable or secret rather than holding the secret.
+func isReference(match, value string) bool {
+	return strings.Contains(match, "from_secret") ||
+		strings.Contains(value, "${") ||
+		strings.HasPrefix(value, "$")
+}
+
+// helper function returns the shannon entropy of the string
+// in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var e float64
+	n := float64(len([]rune(s)))
+	for _, c := range counts {
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// helper function redacts all but the first characters of
+// the value, so the response does not repeat the secret.
+func redact(value string) string {
+	if len(value) <= 4 {
+		return "****"
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
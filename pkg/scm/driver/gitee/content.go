@@ -31,19 +31,51 @@ func (s *contentService) Find(ctx context.Context, repo, path, ref string) (*scm
 }
 
 func (s *contentService) Create(ctx context.Context, repo, path string, params *scm.ContentParams) (*scm.Response, error) {
-	return nil, scm.ErrNotSupported
+	endpoint := fmt.Sprintf("api/v5/repos/%s/contents/%s", repo, url.QueryEscape(path))
+	in := convertContentParams(params)
+	return s.client.do(ctx, "POST", endpoint, in, nil)
 }
 
 func (s *contentService) Update(ctx context.Context, repo, path string, params *scm.ContentParams) (*scm.Response, error) {
-	return nil, scm.ErrNotSupported
+	endpoint := fmt.Sprintf("api/v5/repos/%s/contents/%s", repo, url.QueryEscape(path))
+	in := convertContentParams(params)
+	// gitee requires the blob sha of the file being replaced.
+	// If not provided, the sha of the current file is used.
+	if in.Sha == "" {
+		sha, res, err := s.findSha(ctx, repo, path, in.Branch)
+		if err != nil {
+			return res, err
+		}
+		in.Sha = sha
+	}
+	return s.client.do(ctx, "PUT", endpoint, in, nil)
 }
 
 func (s *contentService) Delete(ctx context.Context, repo, path, ref string) (*scm.Response, error) {
-	return nil, scm.ErrNotSupported
+	sha, res, err := s.findSha(ctx, repo, path, ref)
+	if err != nil {
+		return res, err
+	}
+	params := url.Values{}
+	params.Set("sha", sha)
+	params.Set("message", fmt.Sprintf("Delete %s", path))
+	if branch := scm.TrimRef(ref); branch != "" {
+		params.Set("branch", branch)
+	}
+	endpoint := fmt.Sprintf("api/v5/repos/%s/contents/%s?%s", repo, url.QueryEscape(path), params.Encode())
+	return s.client.do(ctx, "DELETE", endpoint, nil, nil)
 }
 
-func (s *contentService) List(ctx context.Context, repo, path, ref string, _ scm.ListOptions) ([]*scm.ContentInfo, *scm.Response, error) {
+// findSha returns the blob sha of the file at the given ref.
+func (s *contentService) findSha(ctx context.Context, repo, path, ref string) (string, *scm.Response, error) {
 	endpoint := fmt.Sprintf("api/v5/repos/%s/contents/%s?ref=%s", repo, url.QueryEscape(path), scm.TrimRef(ref))
+	out := new(content)
+	res, err := s.client.do(ctx, "GET", endpoint, nil, out)
+	return out.SHA, res, err
+}
+
+func (s *contentService) List(ctx context.Context, repo, path, ref string, opts scm.ListOptions) ([]*scm.ContentInfo, *scm.Response, error) {
+	endpoint := appendQuery(fmt.Sprintf("api/v5/repos/%s/contents/%s?ref=%s", repo, url.QueryEscape(path), scm.TrimRef(ref)), encodeListOptions(opts))
 	out := []*content{}
 	res, err := s.client.do(ctx, "GET", endpoint, nil, &out)
 	return convertContentInfoList(out), res, err
@@ -57,6 +89,42 @@ type content struct {
 	SHA      string `json:"sha"`
 }
 
+type contentCreateUpdate struct {
+	Content   string        `json:"content"`
+	Message   string        `json:"message"`
+	Branch    string        `json:"branch,omitempty"`
+	Sha       string        `json:"sha,omitempty"`
+	Author    *commitAuthor `json:"author,omitempty"`
+	Committer *commitAuthor `json:"committer,omitempty"`
+}
+
+type commitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func convertContentParams(from *scm.ContentParams) *contentCreateUpdate {
+	to := &contentCreateUpdate{
+		Content: base64.StdEncoding.EncodeToString(from.Data),
+		Message: from.Message,
+		Branch:  scm.TrimRef(from.Branch),
+		Sha:     from.Sha,
+	}
+	if to.Branch == "" {
+		to.Branch = scm.TrimRef(from.Ref)
+	}
+	// gitee uses the authenticated user as the author and
+	// committer when no signature is provided.
+	if from.Signature.Name != "" && from.Signature.Email != "" {
+		to.Author = &commitAuthor{
+			Name:  from.Signature.Name,
+			Email: from.Signature.Email,
+		}
+		to.Committer = to.Author
+	}
+	return to
+}
+
 func convertContentInfoList(from []*content) []*scm.ContentInfo {
 	to := []*scm.ContentInfo{}
 	for _, v := range from {
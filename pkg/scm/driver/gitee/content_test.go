@@ -0,0 +1,59 @@
+package gitee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone/go-scm/scm"
+)
+
+func TestContentUpdate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world/contents/.drone.yml", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if got, want := r.URL.Query().Get("ref"), "master"; got != want {
+				t.Errorf("Want ref %s, got %s", want, got)
+			}
+			fmt.Fprint(w, `{"type":"file","path":".drone.yml","sha":"3d21ec53a331a6f037a91c368710b99387d012c1","content":""}`)
+		case "PUT":
+			in := new(contentCreateUpdate)
+			json.NewDecoder(r.Body).Decode(in)
+			if got, want := in.Sha, "3d21ec53a331a6f037a91c368710b99387d012c1"; got != want {
+				t.Errorf("Want sha %s, got %s", want, got)
+			}
+			if got, want := in.Content, "a2luZDogcGlwZWxpbmUK"; got != want {
+				t.Errorf("Want base64 content %s, got %s", want, got)
+			}
+			if got, want := in.Branch, "master"; got != want {
+				t.Errorf("Want branch %s, got %s", want, got)
+			}
+			if in.Author == nil || in.Author.Email != "octocat@example.com" {
+				t.Errorf("Want commit author, got %+v", in.Author)
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	_, err := client.Contents.Update(context.Background(), "oars-sigs/hello-world", ".drone.yml", &scm.ContentParams{
+		Branch:  "refs/heads/master",
+		Message: "Update .drone.yml",
+		Data:    []byte("kind: pipeline\n"),
+		Signature: scm.Signature{
+			Name:  "octocat",
+			Email: "octocat@example.com",
+		},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -26,30 +26,35 @@ func (s *gitService) FindCommit(ctx context.Context, repo, ref string) (*scm.Com
 }
 
 func (s *gitService) FindTag(ctx context.Context, repo, name string) (*scm.Reference, *scm.Response, error) {
-	path := fmt.Sprintf("api/v5/repos/%s/tags", repo)
-	out := []*tag{}
-	res, err := s.client.do(ctx, "GET", path, nil, &out)
-	if err != nil {
-		return nil, res, err
-	}
-	for _, t := range out {
-		if t.Name == scm.TrimRef(name) {
-			return convertTag(t), res, err
+	opts := scm.ListOptions{Size: 100}
+	for {
+		path := appendQuery(fmt.Sprintf("api/v5/repos/%s/tags", repo), encodeListOptions(opts))
+		out := []*tag{}
+		res, err := s.client.do(ctx, "GET", path, nil, &out)
+		if err != nil {
+			return nil, res, err
 		}
-
+		for _, t := range out {
+			if t.Name == scm.TrimRef(name) {
+				return convertTag(t), res, err
+			}
+		}
+		if res.Page.Next == 0 || res.Page.Next == opts.Page {
+			return nil, res, scm.ErrNotFound
+		}
+		opts.Page = res.Page.Next
 	}
-	return nil, res, scm.ErrNotFound
 }
 
 func (s *gitService) ListBranches(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
-	path := fmt.Sprintf("api/v5/repos/%s/branches", repo)
+	path := appendQuery(fmt.Sprintf("api/v5/repos/%s/branches", repo), encodeListOptions(opts))
 	out := []*branch{}
 	res, err := s.client.do(ctx, "GET", path, nil, &out)
 	return convertBranchList(out), res, err
 }
 
-func (s *gitService) ListTags(ctx context.Context, repo string, _ scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
-	path := fmt.Sprintf("api/v5/repos/%s/tags", repo)
+func (s *gitService) ListTags(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
+	path := appendQuery(fmt.Sprintf("api/v5/repos/%s/tags", repo), encodeListOptions(opts))
 	out := []*tag{}
 	res, err := s.client.do(ctx, "GET", path, nil, &out)
 	return convertTagList(out), res, err
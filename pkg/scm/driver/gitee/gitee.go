@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/drone/go-scm/scm"
 )
@@ -49,6 +50,14 @@ type wrapper struct {
 	*scm.Client
 }
 
+// retry settings for idempotent requests that fail because
+// the rate limit is exceeded or gitee is unavailable.
+var (
+	retryMax     = 3
+	retryBackoff = time.Second
+	retryMaxWait = time.Minute
+)
+
 // do wraps the Client.Do function by creating the Request and
 // unmarshalling the response.
 func (c *wrapper) do(ctx context.Context, method, path string, in, out interface{}) (*scm.Response, error) {
@@ -67,19 +76,50 @@ func (c *wrapper) do(ctx context.Context, method, path string, in, out interface
 		req.Body = buf
 	}
 
-	// execute the http request
-	res, err := c.Client.Do(ctx, req)
-	if err != nil {
-		return nil, err
+	// execute the http request. Safe requests are retried
+	// with backoff if the rate limit is exceeded or the
+	// server is temporarily unavailable.
+	var res *scm.Response
+	for attempt := 0; ; attempt++ {
+		var err error
+		res, err = c.Client.Do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if method != "GET" || attempt >= retryMax || !retryable(res.Status) {
+			break
+		}
+		res.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(res, attempt)):
+		}
 	}
 	defer res.Body.Close()
+	populatePageValues(path, res)
+
+	// parse the gitee rate limit details.
+	res.Rate.Limit, _ = strconv.Atoi(
+		res.Header.Get("X-RateLimit-Limit"),
+	)
+	res.Rate.Remaining, _ = strconv.Atoi(
+		res.Header.Get("X-RateLimit-Remaining"),
+	)
+	res.Rate.Reset, _ = strconv.ParseInt(
+		res.Header.Get("X-RateLimit-Reset"), 10, 64,
+	)
+
+	// snapshot the request rate limit
+	c.Client.SetRate(res.Rate)
 
 	// if an error is encountered, unmarshal and return the
 	// error response.
 	if res.Status > 300 {
-		return res, errors.New(
-			http.StatusText(res.Status),
-		)
+		err := &Error{Status: res.Status}
+		json.NewDecoder(res.Body).Decode(err)
+		return res, err
 	}
 
 	if out == nil {
@@ -97,3 +137,36 @@ func (c *wrapper) do(ctx context.Context, method, path string, in, out interface
 	// the json response.
 	return res, json.NewDecoder(res.Body).Decode(out)
 }
+
+// retryable returns true if the request failed because the
+// rate limit is exceeded or the server is unavailable.
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// backoff returns the duration to wait before the next
+// attempt. The Retry-After header is used when provided,
+// otherwise the wait grows exponentially.
+func backoff(res *scm.Response, attempt int) time.Duration {
+	wait := retryBackoff << uint(attempt)
+	if v, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && v > 0 {
+		wait = time.Duration(v) * time.Second
+	}
+	if wait > retryMaxWait {
+		wait = retryMaxWait
+	}
+	return wait
+}
+
+// Error represents a Gitee error.
+type Error struct {
+	Status  int    `json:"-"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return e.Message
+}
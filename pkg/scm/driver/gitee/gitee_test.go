@@ -0,0 +1,84 @@
+package gitee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"message":"Rate limit exceeded"}`)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4998")
+		w.Header().Set("X-RateLimit-Reset", "1604283696")
+		fmt.Fprint(w, `{"id":1234567,"login":"octocat"}`)
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	_, res, err := client.Users.Find(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("Want 3 attempts, got %d", attempts)
+	}
+	if got, want := res.Rate.Remaining, 4998; got != want {
+		t.Errorf("Want rate limit remaining %d, got %d", want, got)
+	}
+	if got, want := client.Rate().Limit, 5000; got != want {
+		t.Errorf("Want rate limit snapshot %d, got %d", want, got)
+	}
+}
+
+func TestClientNoRetryUnsafe(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	_, err := client.Repositories.DeleteHook(context.Background(), "oars-sigs/hello-world", "1")
+	if err == nil {
+		t.Errorf("Expect error deleting hook")
+	}
+	if attempts != 1 {
+		t.Errorf("Want 1 attempt, got %d", attempts)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"没有权限访问该仓库"}`)
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	_, _, err := client.Users.Find(context.Background())
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Want gitee error, got %T", err)
+	}
+	if e.Status != http.StatusForbidden || e.Message != "没有权限访问该仓库" {
+		t.Errorf("Unexpected error %+v", e)
+	}
+}
@@ -0,0 +1,99 @@
+package gitee
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/drone/go-scm/scm"
+)
+
+// Key represents a repository deploy key. Gitee deploy keys
+// are always read-only.
+type Key struct {
+	ID       string
+	Title    string
+	Key      string
+	ReadOnly bool
+	Created  time.Time
+}
+
+// KeyInput provides the input fields required for creating
+// a repository deploy key.
+type KeyInput struct {
+	Title string
+	Key   string
+}
+
+// KeyService manages repository deploy keys. The service is
+// not part of the scm.Client interface and must be created
+// from a Gitee client.
+type KeyService struct {
+	client *wrapper
+}
+
+// NewKeyService returns a new deploy key service for the
+// Gitee client.
+func NewKeyService(client *scm.Client) *KeyService {
+	return &KeyService{&wrapper{client}}
+}
+
+// Find returns the repository deploy key by id.
+func (s *KeyService) Find(ctx context.Context, repo, id string) (*Key, *scm.Response, error) {
+	path := fmt.Sprintf("api/v5/repos/%s/keys/%s", repo, id)
+	out := new(key)
+	res, err := s.client.do(ctx, "GET", path, nil, out)
+	return convertKey(out), res, err
+}
+
+// List returns the repository deploy keys.
+func (s *KeyService) List(ctx context.Context, repo string, opts scm.ListOptions) ([]*Key, *scm.Response, error) {
+	path := appendQuery(fmt.Sprintf("api/v5/repos/%s/keys", repo), encodeListOptions(opts))
+	out := []*key{}
+	res, err := s.client.do(ctx, "GET", path, nil, &out)
+	return convertKeyList(out), res, err
+}
+
+// Create adds a deploy key to the repository.
+func (s *KeyService) Create(ctx context.Context, repo string, input *KeyInput) (*Key, *scm.Response, error) {
+	path := fmt.Sprintf("api/v5/repos/%s/keys", repo)
+	in := &key{
+		Title: input.Title,
+		Key:   input.Key,
+	}
+	out := new(key)
+	res, err := s.client.do(ctx, "POST", path, in, out)
+	return convertKey(out), res, err
+}
+
+// Delete removes the deploy key from the repository.
+func (s *KeyService) Delete(ctx context.Context, repo, id string) (*scm.Response, error) {
+	path := fmt.Sprintf("api/v5/repos/%s/keys/%s", repo, id)
+	return s.client.do(ctx, "DELETE", path, nil, nil)
+}
+
+type key struct {
+	ID      int       `json:"id,omitempty"`
+	Title   string    `json:"title"`
+	Key     string    `json:"key"`
+	Created time.Time `json:"created_at,omitempty"`
+}
+
+func convertKeyList(src []*key) []*Key {
+	var dst []*Key
+	for _, v := range src {
+		dst = append(dst, convertKey(v))
+	}
+	return dst
+}
+
+func convertKey(from *key) *Key {
+	return &Key{
+		ID:       strconv.Itoa(from.ID),
+		Title:    from.Title,
+		Key:      from.Key,
+		ReadOnly: true,
+		Created:  from.Created,
+	}
+}
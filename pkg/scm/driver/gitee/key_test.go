@@ -0,0 +1,39 @@
+package gitee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyCreate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world/keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+		in := new(key)
+		json.NewDecoder(r.Body).Decode(in)
+		if got, want := in.Title, "drone"; got != want {
+			t.Errorf("Want title %s, got %s", want, got)
+		}
+		fmt.Fprint(w, `{"id":42,"title":"drone","key":"ssh-ed25519 AAAA","created_at":"2020-11-02T10:21:36+08:00"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := NewKeyService(client).Create(context.Background(), "oars-sigs/hello-world", &KeyInput{
+		Title: "drone",
+		Key:   "ssh-ed25519 AAAA",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "42" || !got.ReadOnly || got.Created.IsZero() {
+		t.Errorf("Unexpected deploy key %+v", got)
+	}
+}
@@ -12,19 +12,23 @@ type organizationService struct {
 }
 
 func (s *organizationService) Find(ctx context.Context, name string) (*scm.Organization, *scm.Response, error) {
-	path := fmt.Sprintf("api/v5/user/orgs/%s", name)
+	path := fmt.Sprintf("api/v5/orgs/%s", name)
 	out := new(org)
 	res, err := s.client.do(ctx, "GET", path, nil, out)
 	return convertOrg(out), res, err
 }
 
 func (s *organizationService) FindMembership(ctx context.Context, name, username string) (*scm.Membership, *scm.Response, error) {
-	return nil, nil, scm.ErrNotSupported
+	path := fmt.Sprintf("api/v5/orgs/%s/memberships/%s", name, username)
+	out := new(membership)
+	res, err := s.client.do(ctx, "GET", path, nil, out)
+	return convertMembership(out), res, err
 }
 
-func (s *organizationService) List(ctx context.Context, _ scm.ListOptions) ([]*scm.Organization, *scm.Response, error) {
+func (s *organizationService) List(ctx context.Context, opts scm.ListOptions) ([]*scm.Organization, *scm.Response, error) {
+	path := appendQuery("api/v5/user/orgs", encodeListOptions(opts))
 	var out []*org
-	res, err := s.client.do(ctx, "GET", "api/v5/user/orgs", nil, &out)
+	res, err := s.client.do(ctx, "GET", path, nil, &out)
 	return convertOrgList(out), res, err
 }
 
@@ -33,10 +37,16 @@ func (s *organizationService) List(ctx context.Context, _ scm.ListOptions) ([]*s
 //
 
 type org struct {
+	Login  string `json:"login"`
 	Name   string `json:"username"`
 	Avatar string `json:"avatar_url"`
 }
 
+type membership struct {
+	Active bool   `json:"active"`
+	Role   string `json:"role"`
+}
+
 //
 // native data structure conversion
 //
@@ -50,8 +60,27 @@ func convertOrgList(from []*org) []*scm.Organization {
 }
 
 func convertOrg(from *org) *scm.Organization {
+	name := from.Login
+	if name == "" {
+		name = from.Name
+	}
 	return &scm.Organization{
-		Name:   from.Name,
+		Name:   name,
 		Avatar: from.Avatar,
 	}
 }
+
+func convertMembership(from *membership) *scm.Membership {
+	to := &scm.Membership{
+		Active: from.Active,
+	}
+	switch from.Role {
+	case "admin":
+		to.Role = scm.RoleAdmin
+	case "member":
+		to.Role = scm.RoleMember
+	default:
+		to.Role = scm.RoleUndefined
+	}
+	return to
+}
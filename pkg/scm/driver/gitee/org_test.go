@@ -0,0 +1,39 @@
+package gitee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone/go-scm/scm"
+)
+
+func TestOrganizationFindMembership(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/orgs/oars-sigs/memberships/octocat", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/membership.json")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := client.Organizations.FindMembership(context.Background(), "oars-sigs", "octocat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (scm.Membership{Active: true, Role: scm.RoleAdmin}); *got != want {
+		t.Errorf("Want membership %+v, got %+v", want, *got)
+	}
+}
+
+func TestOrganizationFindMembershipNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	_, _, err := client.Organizations.FindMembership(context.Background(), "oars-sigs", "octocat")
+	if err == nil {
+		t.Errorf("Expect error when the user is not a member")
+	}
+}
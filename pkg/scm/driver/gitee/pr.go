@@ -2,6 +2,7 @@ package gitee
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/drone/go-scm/scm"
 )
@@ -10,8 +11,13 @@ type pullService struct {
 	client *wrapper
 }
 
-func (s *pullService) Find(context.Context, string, int) (*scm.PullRequest, *scm.Response, error) {
-	return nil, nil, scm.ErrNotSupported
+// Find returns the pull request. The api response shares
+// the structure of the pull request webhook payload.
+func (s *pullService) Find(ctx context.Context, repo string, number int) (*scm.PullRequest, *scm.Response, error) {
+	path := fmt.Sprintf("api/v5/repos/%s/pulls/%d", repo, number)
+	out := new(PullRequestHook)
+	res, err := s.client.do(ctx, "GET", path, nil, out)
+	return convertPullRequestHook(out), res, err
 }
 
 func (s *pullService) FindComment(context.Context, string, int, int) (*scm.Comment, *scm.Response, error) {
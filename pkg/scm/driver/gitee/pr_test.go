@@ -0,0 +1,37 @@
+package gitee
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPullFind(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadFile("testdata/pr.json")
+		w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := client.PullRequests.Find(context.Background(), "oars-sigs/hello-world", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Ref != "refs/pull/3/head" {
+		t.Errorf("Want pull request ref refs/pull/3/head, got %s", got.Ref)
+	}
+	if got.Sha != "d1fd5a5b80d6cf1b8f2e1f4b6f3b9a4d1c2e3f40" {
+		t.Errorf("Want head sha, got %s", got.Sha)
+	}
+	if got.Source != "feature" || got.Target != "master" {
+		t.Errorf("Want feature into master, got %s into %s", got.Source, got.Target)
+	}
+	if got.Fork != "octocat/hello-world" {
+		t.Errorf("Want fork octocat/hello-world, got %s", got.Fork)
+	}
+}
@@ -32,18 +32,34 @@ func (s *repositoryService) FindPerms(ctx context.Context, repo string) (*scm.Pe
 	path := fmt.Sprintf("/api/v5/repos/%s", repo)
 	out := new(repository)
 	res, err := s.client.do(ctx, "GET", path, nil, out)
-	return convertRepository(out).Perm, res, err
+	if err != nil {
+		return nil, res, err
+	}
+	if out.Permission != (perm{}) {
+		return convertPerm(out.Permission), res, nil
+	}
+	// gitee omits the permission block for some repositories,
+	// in which case the permission level of the authenticated
+	// user is requested from the collaborator endpoint.
+	user, res, err := s.client.Users.Find(ctx)
+	if err != nil {
+		return nil, res, err
+	}
+	path = fmt.Sprintf("api/v5/repos/%s/collaborators/%s/permission", repo, user.Login)
+	level := new(permLevel)
+	res, err = s.client.do(ctx, "GET", path, nil, level)
+	return convertPermLevel(level.Permission), res, err
 }
 
-func (s *repositoryService) List(ctx context.Context, _ scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
-	path := "api/v5/user/repos?visibility=all&affiliation=owner%2C%20collaborator%2C%20organization_member&sort=full_name&direction=asc&page=1&per_page=100"
+func (s *repositoryService) List(ctx context.Context, opts scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	path := appendQuery("api/v5/user/repos?visibility=all&affiliation=owner%2C%20collaborator%2C%20organization_member&sort=full_name&direction=asc", encodeListOptions(opts))
 	out := []*repository{}
 	res, err := s.client.do(ctx, "GET", path, nil, &out)
 	return convertRepositoryList(out), res, err
 }
 
-func (s *repositoryService) ListHooks(ctx context.Context, repo string, _ scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
-	path := fmt.Sprintf("api/v5/repos/%s/hooks", repo)
+func (s *repositoryService) ListHooks(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
+	path := appendQuery(fmt.Sprintf("api/v5/repos/%s/hooks", repo), encodeListOptions(opts))
 	out := []*hook{}
 	res, err := s.client.do(ctx, "GET", path, nil, &out)
 	return convertHookList(out), res, err
@@ -55,14 +71,7 @@ func (s *repositoryService) ListStatus(context.Context, string, string, scm.List
 
 func (s *repositoryService) CreateHook(ctx context.Context, repo string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
 	path := fmt.Sprintf("api/v5/repos/%s/hooks", repo)
-	in := &hook{
-		URL:                 input.Target,
-		EncryptionType:      0,
-		Password:            input.Secret,
-		PushEvents:          true,
-		TagPushEvents:       true,
-		MergeRequestsEvents: true,
-	}
+	in := convertHookInput(input)
 	out := new(hook)
 	res, err := s.client.do(ctx, "POST", path, in, out)
 	return convertHook(out), res, err
@@ -73,25 +82,26 @@ func (s *repositoryService) CreateStatus(context.Context, string, string, *scm.S
 }
 
 func (s *repositoryService) UpdateHook(ctx context.Context, repo, id string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
-	path := fmt.Sprintf("api/v1/repos/%s/hooks/%s", repo, id)
-	in := &hook{
-		URL:                 input.Target,
-		EncryptionType:      0,
-		Password:            input.Secret,
-		PushEvents:          true,
-		TagPushEvents:       true,
-		MergeRequestsEvents: true,
-	}
+	path := fmt.Sprintf("api/v5/repos/%s/hooks/%s", repo, id)
+	in := convertHookInput(input)
 	out := new(hook)
 	res, err := s.client.do(ctx, "PATCH", path, in, out)
 	return convertHook(out), res, err
 }
 
 func (s *repositoryService) DeleteHook(ctx context.Context, repo string, id string) (*scm.Response, error) {
-	path := fmt.Sprintf("api/v1/repos/%s/hooks/%s", repo, id)
+	path := fmt.Sprintf("api/v5/repos/%s/hooks/%s", repo, id)
 	return s.client.do(ctx, "DELETE", path, nil, nil)
 }
 
+// hook encryption types supported by gitee. In signature
+// mode the secret is used to sign the request and is never
+// sent to the webhook endpoint.
+const (
+	encryptionPassword = iota
+	encryptionSignature
+)
+
 type (
 	repository struct {
 		ID            int       `json:"id"`
@@ -115,6 +125,10 @@ type (
 		Pull  bool `json:"pull"`
 	}
 
+	permLevel struct {
+		Permission string `json:"permission"`
+	}
+
 	namespace struct {
 		Path string `json:"path"`
 		Name string `json:"name"`
@@ -155,14 +169,31 @@ func convertRepository(src *repository) *scm.Repository {
 	}
 }
 
+// convertPerm converts the gitee permission block. Each
+// permission implies the permissions below it.
 func convertPerm(src perm) *scm.Perm {
 	return &scm.Perm{
-		Push:  src.Push,
-		Pull:  src.Pull,
+		Push:  src.Push || src.Admin,
+		Pull:  src.Pull || src.Push || src.Admin,
 		Admin: src.Admin,
 	}
 }
 
+// convertPermLevel converts the gitee collaborator permission
+// level (e.g. admin, write, read) to a permission block.
+func convertPermLevel(src string) *scm.Perm {
+	switch src {
+	case "admin":
+		return convertPerm(perm{Admin: true})
+	case "write", "push":
+		return convertPerm(perm{Push: true})
+	case "read", "pull":
+		return convertPerm(perm{Pull: true})
+	default:
+		return convertPerm(perm{})
+	}
+}
+
 func convertHookList(src []*hook) []*scm.Hook {
 	var dst []*scm.Hook
 	for _, v := range src {
@@ -189,11 +220,49 @@ func convertHookEvent(from *hook) []string {
 	if from.IssuesEvents {
 		events = append(events, "issues")
 	}
-	if from.IssuesEvents {
-		events = append(events, "issue_comment")
+	if from.NoteEvents {
+		events = append(events, "note")
 	}
 	if from.PushEvents {
 		events = append(events, "push")
 	}
+	if from.TagPushEvents {
+		events = append(events, "tag_push")
+	}
 	return events
 }
+
+// convertHookInput converts the hook input to a gitee hook.
+// Native events take precedence over the generic events.
+func convertHookInput(from *scm.HookInput) *hook {
+	in := &hook{
+		URL:            from.Target,
+		EncryptionType: encryptionSignature,
+		Password:       from.Secret,
+	}
+	if len(from.NativeEvents) != 0 {
+		for _, event := range from.NativeEvents {
+			switch event {
+			case "push", "push_events":
+				in.PushEvents = true
+			case "tag_push", "tag_push_events":
+				in.TagPushEvents = true
+			case "issues", "issues_events":
+				in.IssuesEvents = true
+			case "note", "note_events":
+				in.NoteEvents = true
+			case "merge_requests", "merge_requests_events":
+				in.MergeRequestsEvents = true
+			}
+		}
+		return in
+	}
+	in.PushEvents = from.Events.Push || from.Events.Branch
+	in.TagPushEvents = from.Events.Tag
+	in.IssuesEvents = from.Events.Issue
+	in.NoteEvents = from.Events.IssueComment ||
+		from.Events.PullRequestComment ||
+		from.Events.ReviewComment
+	in.MergeRequestsEvents = from.Events.PullRequest
+	return in
+}
@@ -0,0 +1,126 @@
+package gitee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/drone/go-scm/scm"
+)
+
+func TestRepositoryFindPerms(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":9876543,"name":"hello-world","permission":{"pull":true,"push":true,"admin":false}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := client.Repositories.FindPerms(context.Background(), "oars-sigs/hello-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (scm.Perm{Pull: true, Push: true}); *got != want {
+		t.Errorf("Want perms %+v, got %+v", want, *got)
+	}
+}
+
+func TestRepositoryFindPermsCollaborator(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":9876543,"name":"hello-world"}`)
+	})
+	mux.HandleFunc("/api/v5/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1234567,"login":"octocat"}`)
+	})
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world/collaborators/octocat/permission", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"permission":"admin","user":{"login":"octocat"}}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := client.Repositories.FindPerms(context.Background(), "oars-sigs/hello-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (scm.Perm{Pull: true, Push: true, Admin: true}); *got != want {
+		t.Errorf("Want perms %+v, got %+v", want, *got)
+	}
+}
+
+func TestRepositoryListPagination(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("page"), "2"; got != want {
+			t.Errorf("Want page %s, got %s", want, got)
+		}
+		if got, want := r.URL.Query().Get("per_page"), "100"; got != want {
+			t.Errorf("Want per_page %s, got %s", want, got)
+		}
+		w.Header().Set("total_count", "250")
+		w.Header().Set("total_page", "3")
+		fmt.Fprint(w, `[{"id":9876543,"name":"hello-world","namespace":{"path":"oars-sigs"}}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, res, err := client.Repositories.List(context.Background(), scm.ListOptions{Page: 2, Size: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("Want 1 repository, got %d", len(got))
+	}
+	if want := (scm.Page{First: 1, Prev: 1, Next: 3, Last: 3}); res.Page != want {
+		t.Errorf("Want page values %+v, got %+v", want, res.Page)
+	}
+}
+
+func TestHookCreateEvents(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/repos/oars-sigs/hello-world/hooks", func(w http.ResponseWriter, r *http.Request) {
+		in := new(hook)
+		json.NewDecoder(r.Body).Decode(in)
+		if !in.MergeRequestsEvents || !in.NoteEvents {
+			t.Errorf("Want merge request and note events enabled")
+		}
+		if in.PushEvents || in.TagPushEvents || in.IssuesEvents {
+			t.Errorf("Want push, tag push and issue events disabled")
+		}
+		fmt.Fprint(w, `{"id":1,"url":"https://drone.example.com/hook","merge_requests_events":true,"note_events":true}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := New(server.URL)
+	got, _, err := client.Repositories.CreateHook(context.Background(), "oars-sigs/hello-world", &scm.HookInput{
+		Target: "https://drone.example.com/hook",
+		Events: scm.HookEvents{
+			PullRequest:        true,
+			PullRequestComment: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pull_request", "note"}; !reflect.DeepEqual(got.Events, want) {
+		t.Errorf("Want hook events %v, got %v", want, got.Events)
+	}
+}
+
+func TestHookInputNativeEvents(t *testing.T) {
+	in := convertHookInput(&scm.HookInput{
+		Events:       scm.HookEvents{Push: true},
+		NativeEvents: []string{"issues_events", "note_events"},
+	})
+	if in.PushEvents || !in.IssuesEvents || !in.NoteEvents {
+		t.Errorf("Want native events to take precedence, got %+v", in)
+	}
+}
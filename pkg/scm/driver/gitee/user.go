@@ -30,14 +30,18 @@ func (s *userService) FindEmail(ctx context.Context) (string, *scm.Response, err
 }
 
 type user struct {
-	ID     int    `json:"id"`
-	Login  string `json:"login"`
-	Name   string `json:"name"`
-	Email  string `json:"email"`
-	Avatar string `json:"avatar_url"`
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Avatar   string `json:"avatar_url"`
 }
 
 func convertUser(src *user) *scm.User {
+	if src == nil {
+		return &scm.User{}
+	}
 	return &scm.User{
 		Login:  src.Login,
 		Avatar: src.Avatar,
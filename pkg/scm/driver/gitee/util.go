@@ -0,0 +1,60 @@
+package gitee
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/drone/go-scm/scm"
+)
+
+func encodeListOptions(opts scm.ListOptions) string {
+	params := url.Values{}
+	if opts.Page != 0 {
+		params.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Size != 0 {
+		params.Set("per_page", strconv.Itoa(opts.Size))
+	}
+	return params.Encode()
+}
+
+// helper function appends the encoded query parameters to
+// the path, if any.
+func appendQuery(path, query string) string {
+	switch {
+	case query == "":
+		return path
+	case strings.Contains(path, "?"):
+		return path + "&" + query
+	default:
+		return path + "?" + query
+	}
+}
+
+// populatePageValues populates the pagination values of
+// the response from the gitee total_page header, when the
+// response does not include a Link header.
+func populatePageValues(path string, res *scm.Response) {
+	if res.Page.Next != 0 || res.Page.Last != 0 {
+		return
+	}
+	total, err := strconv.Atoi(res.Header.Get("total_page"))
+	if err != nil || total == 0 {
+		return
+	}
+	page := 1
+	if uri, err := url.Parse(path); err == nil {
+		if v, err := strconv.Atoi(uri.Query().Get("page")); err == nil && v > 0 {
+			page = v
+		}
+	}
+	res.Page.First = 1
+	res.Page.Last = total
+	if page > 1 {
+		res.Page.Prev = page - 1
+	}
+	if page < total {
+		res.Page.Next = page + 1
+	}
+}
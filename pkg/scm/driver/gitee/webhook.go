@@ -1,11 +1,17 @@
 package gitee
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -31,6 +37,10 @@ func (s *webhookService) Parse(req *http.Request, fn scm.SecretFunc) (scm.Webhoo
 		hook, err = s.parseTagPushHook(data)
 	case "Merge Request Hook":
 		hook, err = s.parsePullRequestHook(data)
+	case "Issue Hook":
+		hook, err = s.parseIssueHook(data)
+	case "Note Hook":
+		hook, err = s.parseNoteHook(data)
 	default:
 		return nil, scm.ErrUnknownEvent
 	}
@@ -38,67 +48,144 @@ func (s *webhookService) Parse(req *http.Request, fn scm.SecretFunc) (scm.Webhoo
 		return nil, err
 	}
 
-	token, err := fn(hook)
+	// get the gitee secret to verify the payload. If no
+	// secret is provided, no validation is performed.
+	key, err := fn(hook)
 	if err != nil {
 		return hook, err
-	} else if token == "" {
+	} else if key == "" {
 		return hook, nil
 	}
 
-	if token != req.Header.Get("X-Gitee-Token") {
+	token := req.Header.Get("X-Gitee-Token")
+	timestamp := req.Header.Get("X-Gitee-Timestamp")
+
+	// fail if no token passed
+	if token == "" {
+		return hook, scm.ErrSignatureInvalid
+	}
+
+	// hooks registered in password mode send the secret
+	// as plaintext in the token header.
+	if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+		return hook, nil
+	}
+
+	// hooks registered in signature mode send a signature
+	// of the timestamp, which must be recent to prevent the
+	// request from being replayed.
+	if !validateTimestamp(timestamp, time.Now()) {
+		return hook, scm.ErrSignatureInvalid
+	}
+	if !validateSignature(token, timestamp, key) {
 		return hook, scm.ErrSignatureInvalid
 	}
 
 	return hook, nil
 }
 
+// signatureTolerance is the maximum age of a signed webhook
+// request, in either direction, before it is rejected.
+const signatureTolerance = 5 * time.Minute
+
+// validateTimestamp returns true if the millisecond unix
+// timestamp is within the signature tolerance of now.
+func validateTimestamp(timestamp string, now time.Time) bool {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	delta := now.Sub(time.Unix(0, ms*int64(time.Millisecond)))
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta <= signatureTolerance
+}
+
+// validateSignature returns true if the signature is the
+// base64 encoded HMAC-SHA256 of the timestamp and secret,
+// as documented by Gitee. The signature may be url encoded.
+func validateSignature(signature, timestamp, key string) bool {
+	if unescaped, err := url.PathUnescape(signature); err == nil {
+		signature = unescaped
+	}
+	return hmac.Equal([]byte(signature), []byte(sign(timestamp, key)))
+}
+
+// sign returns the Gitee webhook signature for the
+// timestamp and secret.
+func sign(timestamp, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "\n" + key))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
 func (s *webhookService) parsePushHook(data []byte) (scm.Webhook, error) {
 	dst := new(PushEvent)
 	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return nil, err
+	}
+	if dst.Ref == nil {
+		return nil, errors.New("gitee: push hook is missing the ref")
+	}
+
+	// gitee sends push hooks when branches are created
+	// without new commits and when branches are deleted.
+	// These are converted to branch hooks.
+	switch {
+	case boolValue(dst.Deleted):
+		return &scm.BranchHook{
+			Action: scm.ActionDelete,
+			Ref: scm.Reference{
+				Name: scm.TrimRef(*dst.Ref),
+				Sha:  stringValue(dst.Before),
+			},
+			Repo:   *convertHookRepository(dst.Repository),
+			Sender: *convertUser(dst.Sender),
+		}, nil
+	case boolValue(dst.Created) && len(dst.Commits) == 0:
+		return &scm.BranchHook{
+			Action: scm.ActionCreate,
+			Ref: scm.Reference{
+				Name: scm.TrimRef(*dst.Ref),
+				Sha:  stringValue(dst.After),
+			},
+			Repo:   *convertHookRepository(dst.Repository),
+			Sender: *convertUser(dst.Sender),
+		}, nil
+	}
+
 	var commits []scm.Commit
 	for _, c := range dst.Commits {
-		commits = append(commits,
-			scm.Commit{
-				Sha:     c.Id,
-				Message: c.Message,
-				Link:    c.Url,
-				Author: scm.Signature{
-					Login: c.Author.Login,
-					Email: c.Author.Email,
-					Name:  c.Author.Name,
-					Date:  c.Timestamp,
-				},
-				Committer: scm.Signature{
-					Login: c.Committer.Login,
-					Email: c.Committer.Email,
-					Name:  c.Committer.Name,
-					Date:  c.Timestamp,
-				},
-			})
+		commits = append(commits, convertHookCommit(&c))
+	}
+
+	// the head commit describes the commit that triggered
+	// the hook. It is not included in some payloads, in
+	// which case the most recent commit is used.
+	head := dst.HeadCommit
+	if head == nil && len(dst.Commits) != 0 {
+		head = &dst.Commits[len(dst.Commits)-1]
 	}
+	commit := scm.Commit{}
+	if head != nil {
+		commit = convertHookCommit(head)
+	}
+	commit.Sha = stringValue(dst.After)
+	if dst.Compare != nil {
+		commit.Link = *dst.Compare
+	}
+
 	return &scm.PushHook{
-		Ref: *dst.Ref,
-		Commit: scm.Commit{
-			Sha:     *dst.After,
-			Message: dst.Commits[0].Message,
-			Link:    *dst.Compare,
-			Author: scm.Signature{
-				Login: dst.Commits[0].Author.Login,
-				Email: dst.Commits[0].Author.Email,
-				Name:  dst.Commits[0].Author.Name,
-				Date:  dst.Commits[0].Timestamp,
-			},
-			Committer: scm.Signature{
-				Login: dst.Commits[0].Committer.Login,
-				Email: dst.Commits[0].Committer.Email,
-				Name:  dst.Commits[0].Committer.Name,
-				Date:  dst.Commits[0].Timestamp,
-			},
-		},
+		Ref:     *dst.Ref,
+		Before:  stringValue(dst.Before),
+		After:   stringValue(dst.After),
+		Commit:  commit,
 		Repo:    *convertHookRepository(dst.Repository),
 		Sender:  *convertUser(dst.Sender),
 		Commits: commits,
-	}, err
+	}, nil
 }
 
 func (s *webhookService) parseTagPushHook(data []byte) (scm.Webhook, error) {
@@ -107,60 +194,222 @@ func (s *webhookService) parseTagPushHook(data []byte) (scm.Webhook, error) {
 	if err != nil {
 		return nil, err
 	}
+	if dst.Ref == nil {
+		return nil, errors.New("gitee: tag push hook is missing the ref")
+	}
 	action := scm.ActionCreate
-	if *dst.Deleted {
+	sha := stringValue(dst.After)
+	if boolValue(dst.Deleted) {
 		action = scm.ActionDelete
+		sha = stringValue(dst.Before)
+	}
+	if dst.HeadCommit != nil {
+		sha = dst.HeadCommit.Id
 	}
 	return &scm.TagHook{
 		Action: action,
 		Ref: scm.Reference{
 			Name: scm.TrimRef(*dst.Ref),
-			Sha:  dst.HeadCommit.Id,
+			Sha:  sha,
 		},
 		Repo:   *convertHookRepository(dst.Repository),
 		Sender: *convertUser(dst.Sender),
-	}, err
+	}, nil
 }
 
 func (s *webhookService) parsePullRequestHook(data []byte) (scm.Webhook, error) {
 	dst := new(PullRequestEvent)
 	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return nil, err
+	}
+	if dst.PullRequest == nil {
+		return nil, errors.New("gitee: merge request hook is missing the pull request")
+	}
 	return &scm.PullRequestHook{
-		Action: convertAction(*dst.Action),
-		PullRequest: scm.PullRequest{
-			Number: dst.PullRequest.Number,
-			Title:  dst.PullRequest.Title,
-			Body:   dst.PullRequest.Body,
-			Closed: dst.PullRequest.State == "closed",
-			Author: scm.User{
-				Login:  dst.PullRequest.User.Login,
-				Email:  dst.PullRequest.User.Email,
-				Avatar: dst.PullRequest.User.Avatar,
-			},
-			Merged: dst.PullRequest.Merged,
-			// Created: nil,
-			// Updated: nil,
-			Source: dst.PullRequest.Head.Ref,
-			Target: dst.PullRequest.Base.Ref,
-			Link:   dst.PullRequest.HtmlUrl,
-			Fork:   dst.PullRequest.Head.Repo.FullName,
-			Ref:    fmt.Sprintf("refs/pull/%d/head", dst.PullRequest.Number),
-			Sha:    dst.PullRequest.Head.Sha,
-		},
+		Action:      convertAction(stringValue(dst.Action)),
+		PullRequest: *convertPullRequestHook(dst.PullRequest),
+		Repo:        *convertHookRepository(dst.Repository),
+		Sender:      *convertUser(dst.Sender),
+	}, nil
+}
+
+func (s *webhookService) parseIssueHook(data []byte) (scm.Webhook, error) {
+	dst := new(IssueEvent)
+	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return nil, err
+	}
+	if dst.Issue == nil {
+		return nil, errors.New("gitee: issue hook is missing the issue")
+	}
+	return &scm.IssueHook{
+		Action: convertAction(stringValue(dst.Action)),
+		Issue:  *convertIssueHook(dst.Issue),
 		Repo:   *convertHookRepository(dst.Repository),
 		Sender: *convertUser(dst.Sender),
-	}, err
+	}, nil
+}
+
+func (s *webhookService) parseNoteHook(data []byte) (scm.Webhook, error) {
+	dst := new(NoteEvent)
+	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return nil, err
+	}
+	if dst.Comment == nil {
+		return nil, errors.New("gitee: note hook is missing the comment")
+	}
+
+	// gitee sends a single note hook for comments on pull
+	// requests, issues and commits. Comments on commits are
+	// not supported.
+	switch stringValue(dst.NoteableType) {
+	case "PullRequest":
+		if dst.PullRequest == nil {
+			return nil, errors.New("gitee: note hook is missing the pull request")
+		}
+		return &scm.PullRequestCommentHook{
+			Action:      convertCommentAction(stringValue(dst.Action)),
+			PullRequest: *convertPullRequestHook(dst.PullRequest),
+			Comment:     *convertNoteHook(dst.Comment),
+			Repo:        *convertHookRepository(dst.Repository),
+			Sender:      *convertUser(dst.Sender),
+		}, nil
+	case "Issue":
+		if dst.Issue == nil {
+			return nil, errors.New("gitee: note hook is missing the issue")
+		}
+		return &scm.IssueCommentHook{
+			Action:  convertCommentAction(stringValue(dst.Action)),
+			Issue:   *convertIssueHook(dst.Issue),
+			Comment: *convertNoteHook(dst.Comment),
+			Repo:    *convertHookRepository(dst.Repository),
+			Sender:  *convertUser(dst.Sender),
+		}, nil
+	default:
+		return nil, scm.ErrUnknownEvent
+	}
+}
+
+func convertPullRequestHook(src *PullRequestHook) *scm.PullRequest {
+	dst := &scm.PullRequest{
+		Number:  src.Number,
+		Title:   src.Title,
+		Body:    src.Body,
+		Closed:  src.State == "closed",
+		Merged:  src.Merged,
+		Link:    src.HtmlUrl,
+		Diff:    src.DiffUrl,
+		Ref:     fmt.Sprintf("refs/pull/%d/head", src.Number),
+		Author:  *convertUser(src.User),
+		Created: parseHookTime(src.CreatedAt),
+		Updated: parseHookTime(src.UpdatedAt),
+	}
+	if src.Head != nil {
+		dst.Source = src.Head.Ref
+		dst.Sha = src.Head.Sha
+		dst.Head = scm.Reference{
+			Name: src.Head.Ref,
+			Path: scm.ExpandRef(src.Head.Ref, "refs/heads"),
+			Sha:  src.Head.Sha,
+		}
+		if src.Head.Repo != nil {
+			dst.Fork = src.Head.Repo.FullName
+		}
+	}
+	if src.Base != nil {
+		dst.Target = src.Base.Ref
+		dst.Base = scm.Reference{
+			Name: src.Base.Ref,
+			Path: scm.ExpandRef(src.Base.Ref, "refs/heads"),
+			Sha:  src.Base.Sha,
+		}
+	}
+	return dst
+}
+
+func convertIssueHook(src *IssueHook) *scm.Issue {
+	dst := &scm.Issue{
+		Title:   src.Title,
+		Body:    src.Body,
+		Link:    src.HtmlUrl,
+		Closed:  src.State == "closed",
+		Author:  *convertUser(src.User),
+		Created: src.CreatedAt,
+		Updated: src.UpdatedAt,
+	}
+	// gitee issue numbers are alphanumeric identifiers (e.g.
+	// I1ABCD) that cannot be represented as an integer.
+	dst.Number, _ = strconv.Atoi(src.Number)
+	for _, label := range src.Labels {
+		dst.Labels = append(dst.Labels, label.Name)
+	}
+	return dst
+}
+
+func convertNoteHook(src *NoteHook) *scm.Comment {
+	return &scm.Comment{
+		ID:      src.Id,
+		Body:    src.Body,
+		Author:  *convertUser(src.User),
+		Created: src.CreatedAt,
+		Updated: src.UpdatedAt,
+	}
+}
+
+// helper function parses a timestamp in the webhook payload,
+// returning the zero value if the timestamp is invalid.
+func parseHookTime(src string) time.Time {
+	t, _ := time.Parse(time.RFC3339, src)
+	return t
+}
+
+func convertHookCommit(src *CommitHook) scm.Commit {
+	return scm.Commit{
+		Sha:       src.Id,
+		Message:   src.Message,
+		Link:      src.Url,
+		Author:    convertHookSignature(src.Author, src.Timestamp),
+		Committer: convertHookSignature(src.Committer, src.Timestamp),
+	}
+}
+
+func convertHookSignature(src *user, date time.Time) scm.Signature {
+	if src == nil {
+		return scm.Signature{Date: date}
+	}
+	login := src.Login
+	if login == "" {
+		login = src.Username
+	}
+	return scm.Signature{
+		Login:  login,
+		Email:  src.Email,
+		Name:   src.Name,
+		Avatar: src.Avatar,
+		Date:   date,
+	}
 }
 
 func convertHookRepository(src *ProjectHook) *scm.Repository {
+	if src == nil {
+		return &scm.Repository{}
+	}
+	var namespace string
+	if src.Owner != nil {
+		namespace = src.Owner.Login
+	}
+	if namespace == "" {
+		namespace = src.Namespace
+	}
 	return &scm.Repository{
 		ID:        strconv.Itoa(src.Id),
-		Namespace: src.Owner.Login,
+		Namespace: namespace,
 		Name:      src.Name,
-		Perm: &scm.Perm{
-			Pull: true,
-			Push: true,
-		},
+		// webhook payloads do not include the permissions of
+		// the user; use FindPerms to look them up.
+		Perm:     &scm.Perm{},
 		Branch:   src.DefaultBranch,
 		Private:  src.Private,
 		Clone:    src.GitHttpUrl,
@@ -224,6 +473,47 @@ type PullRequestEvent struct {
 	Password       *string          `json:"password,omitempty"`
 }
 
+type IssueEvent struct {
+	Action      *string         `json:"action,omitempty"`
+	Issue       *IssueHook      `json:"issue,omitempty"`
+	Repository  *ProjectHook    `json:"repository,omitempty"`
+	Project     *ProjectHook    `json:"project,omitempty"`
+	Sender      *user           `json:"sender,omitempty"`
+	TargetUser  *user           `json:"target_user,omitempty"`
+	User        *user           `json:"user,omitempty"`
+	Assignee    *user           `json:"assignee,omitempty"`
+	UpdatedBy   *user           `json:"updated_by,omitempty"`
+	Iid         *string         `json:"iid,omitempty"`
+	Title       *string         `json:"title,omitempty"`
+	Description *string         `json:"description,omitempty"`
+	State       *string         `json:"state,omitempty"`
+	URL         *string         `json:"url,omitempty"`
+	Enterprise  *EnterpriseHook `json:"enterprise,omitempty"`
+	HookName    *string         `json:"hook_name,omitempty"`
+	Password    *string         `json:"password,omitempty"`
+}
+
+type NoteEvent struct {
+	Action        *string          `json:"action,omitempty"`
+	Comment       *NoteHook        `json:"comment,omitempty"`
+	Repository    *ProjectHook     `json:"repository,omitempty"`
+	Project       *ProjectHook     `json:"project,omitempty"`
+	Author        *user            `json:"author,omitempty"`
+	Sender        *user            `json:"sender,omitempty"`
+	URL           *string          `json:"url,omitempty"`
+	Note          *string          `json:"note,omitempty"`
+	NoteableType  *string          `json:"noteable_type,omitempty"`
+	NoteableID    int64            `json:"noteable_id,omitempty"`
+	Title         *string          `json:"title,omitempty"`
+	PerIid        *string          `json:"per_iid,omitempty"`
+	ShortCommitID *string          `json:"short_commit_id,omitempty"`
+	Issue         *IssueHook       `json:"issue,omitempty"`
+	PullRequest   *PullRequestHook `json:"pull_request,omitempty"`
+	Enterprise    *EnterpriseHook  `json:"enterprise,omitempty"`
+	HookName      *string          `json:"hook_name,omitempty"`
+	Password      *string          `json:"password,omitempty"`
+}
+
 type TagPushEvent struct {
 	Action *string `json:"action,omitempty"`
 }
@@ -291,6 +581,18 @@ type IssueHook struct {
 	Body          string         `json:"body,omitempty"`
 }
 
+// NoteHook : 评论信息
+type NoteHook struct {
+	Id        int       `json:"id,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	User      *user     `json:"user,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	HtmlUrl   string    `json:"html_url,omitempty"`
+	Position  string    `json:"position,omitempty"`
+	CommitId  string    `json:"commit_id,omitempty"`
+}
+
 // ProjectHook : project 信息
 type ProjectHook struct {
 	Id              int    `json:"id,omitempty"`
@@ -379,6 +681,33 @@ type PullRequestHook struct {
 	ChangedFiles       int            `json:"changed_files,omitempty"`
 }
 
+// helper function returns the value of the string pointer,
+// or the empty string if the pointer is nil.
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// helper function returns the value of the bool pointer,
+// or false if the pointer is nil.
+func boolValue(v *bool) bool {
+	if v == nil {
+		return false
+	}
+	return *v
+}
+
+// convertCommentAction converts the note hook action. Gitee
+// reports new comments with the "comment" action.
+func convertCommentAction(src string) scm.Action {
+	if src == "comment" {
+		return scm.ActionCreate
+	}
+	return convertAction(src)
+}
+
 func convertAction(src string) (action scm.Action) {
 	switch src {
 	case "create", "created":
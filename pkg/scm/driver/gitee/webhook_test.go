@@ -0,0 +1,245 @@
+package gitee
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drone/go-scm/scm"
+)
+
+func TestWebhooks(t *testing.T) {
+	tests := []struct {
+		event  string
+		before string
+		after  string
+		obj    interface{}
+	}{
+		// branch hooks
+		{
+			event:  "Push Hook",
+			before: "testdata/webhooks/branch_create.json",
+			after:  "testdata/webhooks/branch_create.json.golden",
+			obj:    new(scm.BranchHook),
+		},
+		{
+			event:  "Push Hook",
+			before: "testdata/webhooks/branch_delete.json",
+			after:  "testdata/webhooks/branch_delete.json.golden",
+			obj:    new(scm.BranchHook),
+		},
+		// tag hooks
+		{
+			event:  "Tag Push Hook",
+			before: "testdata/webhooks/tag_create.json",
+			after:  "testdata/webhooks/tag_create.json.golden",
+			obj:    new(scm.TagHook),
+		},
+		{
+			event:  "Tag Push Hook",
+			before: "testdata/webhooks/tag_delete.json",
+			after:  "testdata/webhooks/tag_delete.json.golden",
+			obj:    new(scm.TagHook),
+		},
+		// push hooks
+		{
+			event:  "Push Hook",
+			before: "testdata/webhooks/push.json",
+			after:  "testdata/webhooks/push.json.golden",
+			obj:    new(scm.PushHook),
+		},
+		{
+			event:  "Push Hook",
+			before: "testdata/webhooks/push_branch_create.json",
+			after:  "testdata/webhooks/push_branch_create.json.golden",
+			obj:    new(scm.PushHook),
+		},
+		{
+			event:  "Push Hook",
+			before: "testdata/webhooks/push_minimal.json",
+			after:  "testdata/webhooks/push_minimal.json.golden",
+			obj:    new(scm.PushHook),
+		},
+		// pull request hooks
+		{
+			event:  "Merge Request Hook",
+			before: "testdata/webhooks/pull_request_opened.json",
+			after:  "testdata/webhooks/pull_request_opened.json.golden",
+			obj:    new(scm.PullRequestHook),
+		},
+		// issue hooks
+		{
+			event:  "Issue Hook",
+			before: "testdata/webhooks/issue_opened.json",
+			after:  "testdata/webhooks/issue_opened.json.golden",
+			obj:    new(scm.IssueHook),
+		},
+		// note hooks
+		{
+			event:  "Note Hook",
+			before: "testdata/webhooks/pull_request_comment.json",
+			after:  "testdata/webhooks/pull_request_comment.json.golden",
+			obj:    new(scm.PullRequestCommentHook),
+		},
+		{
+			event:  "Note Hook",
+			before: "testdata/webhooks/issue_comment.json",
+			after:  "testdata/webhooks/issue_comment.json.golden",
+			obj:    new(scm.IssueCommentHook),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.before, func(t *testing.T) {
+			after, err := ioutil.ReadFile(test.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := new(webhookService)
+			o, err := s.Parse(newHookRequest(t, test.event, test.before), secretFunc(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := json.Unmarshal(after, test.obj); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := reflect.TypeOf(o), reflect.TypeOf(test.obj); got != want {
+				t.Fatalf("Want hook type %s, got %s", want, got)
+			}
+
+			// compare the json encoded hooks, since decoded
+			// timestamps do not share a location pointer.
+			want, _ := json.MarshalIndent(test.obj, "", "  ")
+			got, _ := json.MarshalIndent(o, "", "  ")
+			if !bytes.Equal(got, want) {
+				t.Errorf("Unexpected hook for %s", test.before)
+				t.Log(string(got))
+			}
+
+			switch event := o.(type) {
+			case *scm.PushHook:
+				if !strings.HasPrefix(event.Ref, "refs/") {
+					t.Errorf("Push hook reference must start with refs/")
+				}
+			case *scm.BranchHook:
+				if strings.HasPrefix(event.Ref.Name, "refs/") {
+					t.Errorf("Branch hook reference must not start with refs/")
+				}
+			case *scm.TagHook:
+				if strings.HasPrefix(event.Ref.Name, "refs/") {
+					t.Errorf("Tag hook reference must not start with refs/")
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookMalformed(t *testing.T) {
+	for _, data := range []string{
+		`{"ref":`,
+		`{}`,
+		`{"ref":null,"deleted":true}`,
+	} {
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(data))
+		req.Header.Set("X-Gitee-Event", "Push Hook")
+
+		s := new(webhookService)
+		if _, err := s.Parse(req, secretFunc("")); err == nil {
+			t.Errorf("Expect error parsing malformed payload %s", data)
+		}
+	}
+}
+
+func TestWebhookUnknownEvent(t *testing.T) {
+	tests := []struct {
+		event string
+		path  string
+	}{
+		{"Wiki Hook", "testdata/webhooks/push.json"},
+		{"Note Hook", "testdata/webhooks/commit_comment.json"},
+	}
+	for _, test := range tests {
+		req := newHookRequest(t, test.event, test.path)
+
+		s := new(webhookService)
+		_, err := s.Parse(req, secretFunc(""))
+		if err != scm.ErrUnknownEvent {
+			t.Errorf("Expect unknown event error for %s, got %v", test.path, err)
+		}
+	}
+}
+
+func TestWebhookPassword(t *testing.T) {
+	req := newHookRequest(t, "Push Hook", "testdata/webhooks/push.json")
+	req.Header.Set("X-Gitee-Token", "topsecret")
+
+	s := new(webhookService)
+	_, err := s.Parse(req, secretFunc("topsecret"))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	req := newHookRequest(t, "Push Hook", "testdata/webhooks/push.json")
+	req.Header.Set("X-Gitee-Token", sign(timestamp, "topsecret"))
+	req.Header.Set("X-Gitee-Timestamp", timestamp)
+
+	s := new(webhookService)
+	_, err := s.Parse(req, secretFunc("topsecret"))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebhookSignatureInvalid(t *testing.T) {
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	req := newHookRequest(t, "Push Hook", "testdata/webhooks/push.json")
+	req.Header.Set("X-Gitee-Token", sign(timestamp, "wrongsecret"))
+	req.Header.Set("X-Gitee-Timestamp", timestamp)
+
+	s := new(webhookService)
+	_, err := s.Parse(req, secretFunc("topsecret"))
+	if err != scm.ErrSignatureInvalid {
+		t.Errorf("Expect invalid signature error, got %v", err)
+	}
+}
+
+func TestWebhookSignatureExpired(t *testing.T) {
+	expired := time.Now().Add(-signatureTolerance - time.Minute)
+	timestamp := strconv.FormatInt(expired.UnixNano()/int64(time.Millisecond), 10)
+	req := newHookRequest(t, "Push Hook", "testdata/webhooks/push.json")
+	req.Header.Set("X-Gitee-Token", sign(timestamp, "topsecret"))
+	req.Header.Set("X-Gitee-Timestamp", timestamp)
+
+	s := new(webhookService)
+	_, err := s.Parse(req, secretFunc("topsecret"))
+	if err != scm.ErrSignatureInvalid {
+		t.Errorf("Expect invalid signature error, got %v", err)
+	}
+}
+
+func newHookRequest(t *testing.T, event, path string) *http.Request {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(data))
+	req.Header.Set("X-Gitee-Event", event)
+	return req
+}
+
+func secretFunc(secret string) scm.SecretFunc {
+	return func(scm.Webhook) (string, error) {
+		return secret, nil
+	}
+}
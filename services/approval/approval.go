@@ -0,0 +1,34 @@
+package approval
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+)
+
+// New returns a ValidateService that blocks builds of
+// pipelines with an approval policy matching the build
+// event. Blocked builds run once approved.
+func New(pipelineStore model.PipelineStore) core.ValidateService {
+	return &validator{pipelineStore: pipelineStore}
+}
+
+type validator struct {
+	pipelineStore model.PipelineStore
+}
+
+func (v *validator) Validate(ctx context.Context, req *core.ValidateArgs) error {
+	pipe, err := v.pipelineStore.FindPipeline(ctx, req.Repo, req.Build.Ref)
+	if err != nil {
+		return err
+	}
+	if pipe == nil || pipe.Approval == nil {
+		return nil
+	}
+	if pipe.Approval.Match(req.Build.Event) {
+		return core.ErrValidatorBlock
+	}
+	return nil
+}
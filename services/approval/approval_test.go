@@ -0,0 +1,56 @@
+package approval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+)
+
+func TestValidate(t *testing.T) {
+	store := &pipelineStore{
+		pipe: &model.Pipeline{
+			Approval: &model.ApprovalPolicy{
+				MinApprovals: 1,
+				Events:       []string{core.EventPromote},
+			},
+		},
+	}
+	tests := []struct {
+		event string
+		err   error
+	}{
+		{core.EventPromote, core.ErrValidatorBlock},
+		{core.EventPush, nil},
+	}
+	for _, test := range tests {
+		err := New(store).Validate(context.Background(), &core.ValidateArgs{
+			Repo:  &core.Repository{Slug: "octocat/hello-world"},
+			Build: &core.Build{Ref: "refs/heads/master", Event: test.event},
+		})
+		if err != test.err {
+			t.Errorf("Want error %v for event %s, got %v", test.err, test.event, err)
+		}
+	}
+}
+
+func TestValidateNoPolicy(t *testing.T) {
+	err := New(&pipelineStore{}).Validate(context.Background(), &core.ValidateArgs{
+		Repo:  &core.Repository{Slug: "octocat/hello-world"},
+		Build: &core.Build{Ref: "refs/heads/master", Event: core.EventPush},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type pipelineStore struct {
+	model.PipelineStore
+	pipe *model.Pipeline
+}
+
+func (s *pipelineStore) FindPipeline(context.Context, *core.Repository, string) (*model.Pipeline, error) {
+	return s.pipe, nil
+}
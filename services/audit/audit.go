@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// New returns an AuditStore that persists the audit log entries
+// to the store and, if export is true, also sends them to the
+// global webhook endpoints.
+func New(store model.AuditStore, webhook model.WebhookSender, export bool) model.AuditStore {
+	return &service{
+		AuditStore: store,
+		webhook:    webhook,
+		export:     export,
+	}
+}
+
+type service struct {
+	model.AuditStore
+	webhook model.WebhookSender
+	export  bool
+}
+
+func (s *service) Create(ctx context.Context, audit *model.Audit) error {
+	err := s.AuditStore.Create(ctx, audit)
+	if err != nil || !s.export {
+		return err
+	}
+	// the entry is exported in the background, so a slow
+	// endpoint does not delay the audited request.
+	go func(audit model.Audit) {
+		err := s.webhook.SendEvent(context.Background(), model.WebhookEventAudit, "", &audit)
+		if err != nil {
+			logrus.WithError(err).
+				WithField("audit", audit.ID).
+				Warnln("audit: cannot export the audit log entry")
+		}
+	}(*audit)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/scm/driver/gitee"
 
 	"github.com/drone/drone/core"
 	"github.com/drone/drone/handler/api/request"
@@ -46,20 +47,39 @@ func (s *service) FindBranches(ctx context.Context, user *core.User, repo string
 	if err != nil {
 		return nil, err
 	}
-	references, _, err := s.client.Git.ListBranches(ctx, repo, scm.ListOptions{})
-	return references, err
+	references := []*scm.Reference{}
+	opts := scm.ListOptions{Size: 100}
+	for {
+		result, meta, err := s.client.Git.ListBranches(ctx, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, result...)
+		if meta.Page.Next == 0 || meta.Page.Next == opts.Page {
+			return references, nil
+		}
+		opts.Page = meta.Page.Next
+	}
 }
+
 func (s *service) FindTags(ctx context.Context, user *core.User, repo string) ([]*scm.Reference, error) {
-	err := s.renew.Renew(ctx, user, false)
+	ctx, err := s.userContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
-		Token:   user.Token,
-		Refresh: user.Refresh,
-	})
-	references, _, err := s.client.Git.ListTags(ctx, repo, scm.ListOptions{})
-	return references, nil
+	references := []*scm.Reference{}
+	opts := scm.ListOptions{Size: 100}
+	for {
+		result, meta, err := s.client.Git.ListTags(ctx, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, result...)
+		if meta.Page.Next == 0 || meta.Page.Next == opts.Page {
+			return references, nil
+		}
+		opts.Page = meta.Page.Next
+	}
 }
 
 func (s *service) FindFile(ctx context.Context, user *core.User, repo, path, branch string) (*scm.Content, *scm.Response, error) {
@@ -106,3 +126,54 @@ func (s *service) UpdateFile(ctx context.Context, user *core.User, repo, path st
 	_, err = s.client.Contents.Update(ctx, repo, path, params)
 	return err
 }
+
+func (s *service) FindPullRequest(ctx context.Context, user *core.User, repo string, number int) (*scm.PullRequest, error) {
+	err := s.renew.Renew(ctx, user, false)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
+		Token:   user.Token,
+		Refresh: user.Refresh,
+	})
+	pr, _, err := s.client.PullRequests.Find(ctx, repo, number)
+	return pr, err
+}
+
+func (s *service) CreateDeployKey(ctx context.Context, user *core.User, repo, title, key string) (string, error) {
+	if s.client.Driver != gitee.DriverGitee {
+		return "", scm.ErrNotSupported
+	}
+	err := s.renew.Renew(ctx, user, false)
+	if err != nil {
+		return "", err
+	}
+	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
+		Token:   user.Token,
+		Refresh: user.Refresh,
+	})
+	out, _, err := gitee.NewKeyService(s.client).Create(ctx, repo, &gitee.KeyInput{
+		Title: title,
+		Key:   key,
+	})
+	if err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+func (s *service) DeleteDeployKey(ctx context.Context, user *core.User, repo, id string) error {
+	if s.client.Driver != gitee.DriverGitee {
+		return scm.ErrNotSupported
+	}
+	err := s.renew.Renew(ctx, user, false)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
+		Token:   user.Token,
+		Refresh: user.Refresh,
+	})
+	_, err = gitee.NewKeyService(s.client).Delete(ctx, repo, id)
+	return err
+}
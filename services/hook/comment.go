@@ -0,0 +1,245 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/go-scm/scm"
+	"github.com/sirupsen/logrus"
+)
+
+// supported comment commands.
+const (
+	commandBuild = "build"
+	commandRetry = "retry"
+)
+
+// NewCommentParser returns a HookParser that converts pull
+// request comments starting with the command prefix (e.g.
+// /drone) into build hooks. All other webhooks are handled by
+// the wrapped parser. If the prefix is empty, comments are
+// ignored.
+func NewCommentParser(
+	parser core.HookParser,
+	client *scm.Client,
+	repos core.RepositoryStore,
+	builds core.BuildStore,
+	users core.UserStore,
+	perms core.PermStore,
+	prefix string,
+) core.HookParser {
+	return &commentParser{
+		parser: parser,
+		client: client,
+		repos:  repos,
+		builds: builds,
+		users:  users,
+		perms:  perms,
+		prefix: strings.TrimSpace(prefix),
+	}
+}
+
+type commentParser struct {
+	parser core.HookParser
+	client *scm.Client
+	repos  core.RepositoryStore
+	builds core.BuildStore
+	users  core.UserStore
+	perms  core.PermStore
+	prefix string
+}
+
+func (p *commentParser) Parse(req *http.Request, secretFunc func(string) string) (*core.Hook, *core.Repository, error) {
+	if p.prefix == "" {
+		return p.parser.Parse(req, secretFunc)
+	}
+
+	// the request body is buffered so that it can be parsed
+	// a second time if the wrapped parser ignores the hook.
+	data, err := ioutil.ReadAll(
+		io.LimitReader(req.Body, 10000000),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	hook, repo, err := p.parser.Parse(req, secretFunc)
+	if err != nil || hook != nil {
+		return hook, repo, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	payload, err := p.client.Webhooks.Parse(req, func(webhook scm.Webhook) (string, error) {
+		repo := webhook.Repository()
+		secret := secretFunc(scm.Join(repo.Namespace, repo.Name))
+		if secret == "" {
+			return secret, errors.New("Cannot find repository")
+		}
+		return secret, nil
+	})
+	if err == scm.ErrUnknownEvent {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v, ok := payload.(*scm.PullRequestCommentHook)
+	if !ok || v.Action != scm.ActionCreate {
+		return nil, nil, nil
+	}
+	command, ok := p.command(v.Comment.Body)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	ctx := req.Context()
+	log := logrus.WithFields(
+		logrus.Fields{
+			"namespace": v.Repo.Namespace,
+			"name":      v.Repo.Name,
+			"pr":        v.PullRequest.Number,
+			"command":   command,
+			"sender":    v.Sender.Login,
+		},
+	)
+
+	stored, err := p.repos.FindName(ctx, v.Repo.Namespace, v.Repo.Name)
+	if err != nil {
+		log.WithError(err).Debugln("comment: cannot find repository")
+		return nil, nil, nil
+	}
+	if !p.canWrite(ctx, stored, v.Sender.Login) {
+		log.Debugln("comment: ignore command, sender cannot write to the repository")
+		return nil, nil, nil
+	}
+
+	switch command {
+	case commandBuild:
+		hook = p.build(v)
+	case commandRetry:
+		hook = p.retry(ctx, stored, v)
+	default:
+		log.Debugln("comment: ignore unknown command")
+		return nil, nil, nil
+	}
+
+	log.Debugln("comment: command accepted")
+	return hook, &core.Repository{
+		UID:       v.Repo.ID,
+		Namespace: v.Repo.Namespace,
+		Name:      v.Repo.Name,
+		Slug:      scm.Join(v.Repo.Namespace, v.Repo.Name),
+		Link:      v.Repo.Link,
+		Branch:    v.Repo.Branch,
+		Private:   v.Repo.Private,
+		HTTPURL:   v.Repo.Clone,
+		SSHURL:    v.Repo.CloneSSH,
+	}, nil
+}
+
+// command returns the command of the comment if the first
+// line starts with the command prefix.
+func (p *commentParser) command(body string) (string, bool) {
+	line := strings.TrimSpace(strings.SplitN(body, "\n", 2)[0])
+	if !strings.HasPrefix(line, p.prefix) {
+		return "", false
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, p.prefix))
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
+
+// canWrite returns true if the sender is a registered user
+// with write access to the repository.
+func (p *commentParser) canWrite(ctx context.Context, repo *core.Repository, login string) bool {
+	user, err := p.users.FindLogin(ctx, login)
+	if err != nil {
+		return false
+	}
+	if user.Admin {
+		return true
+	}
+	perm, err := p.perms.Find(ctx, repo.UID, user.ID)
+	if err != nil {
+		return false
+	}
+	return perm.Write || perm.Admin
+}
+
+// build returns a hook that builds the head of the pull
+// request.
+func (p *commentParser) build(v *scm.PullRequestCommentHook) *core.Hook {
+	hook := &core.Hook{
+		Trigger:      v.Sender.Login,
+		Event:        core.EventPullRequest,
+		Action:       core.ActionSync,
+		Link:         v.PullRequest.Link,
+		Timestamp:    v.Comment.Created.Unix(),
+		Title:        v.PullRequest.Title,
+		Message:      v.PullRequest.Body,
+		Before:       v.PullRequest.Base.Sha,
+		After:        v.PullRequest.Sha,
+		Ref:          v.PullRequest.Ref,
+		Fork:         v.PullRequest.Fork,
+		Source:       v.PullRequest.Source,
+		Target:       v.PullRequest.Target,
+		Author:       v.PullRequest.Author.Login,
+		AuthorName:   v.PullRequest.Author.Name,
+		AuthorEmail:  v.PullRequest.Author.Email,
+		AuthorAvatar: v.PullRequest.Author.Avatar,
+		Sender:       v.Sender.Login,
+		Params:       map[string]string{},
+	}
+	if hook.Message == "" {
+		hook.Message = hook.Title
+	}
+	return hook
+}
+
+// retry returns a hook that restarts the most recent build
+// of the pull request. If the pull request has not been built,
+// the head of the pull request is built.
+func (p *commentParser) retry(ctx context.Context, repo *core.Repository, v *scm.PullRequestCommentHook) *core.Hook {
+	prev, err := p.builds.FindRef(ctx, repo.ID, v.PullRequest.Ref)
+	if err != nil {
+		return p.build(v)
+	}
+	hook := &core.Hook{
+		Parent:       prev.Number,
+		Trigger:      v.Sender.Login,
+		Event:        prev.Event,
+		Action:       prev.Action,
+		Link:         prev.Link,
+		Timestamp:    prev.Timestamp,
+		Title:        prev.Title,
+		Message:      prev.Message,
+		Before:       prev.Before,
+		After:        prev.After,
+		Ref:          prev.Ref,
+		Fork:         prev.Fork,
+		Source:       prev.Source,
+		Target:       prev.Target,
+		Author:       prev.Author,
+		AuthorName:   prev.AuthorName,
+		AuthorEmail:  prev.AuthorEmail,
+		AuthorAvatar: prev.AuthorAvatar,
+		Deployment:   prev.Deploy,
+		DeploymentID: prev.DeployID,
+		Cron:         prev.Cron,
+		Sender:       prev.Sender,
+		Params:       map[string]string{},
+	}
+	for key, value := range prev.Params {
+		hook.Params[key] = value
+	}
+	return hook
+}
@@ -0,0 +1,101 @@
+package hook
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/go-scm/scm"
+)
+
+// New returns a new HookService. If comments is true, the
+// repository webhook also subscribes to comment events so
+// that comment commands can trigger builds.
+func New(client *scm.Client, addr string, renew core.Renewer, comments bool) core.HookService {
+	return &service{client: client, addr: addr, renew: renew, comments: comments}
+}
+
+type service struct {
+	renew    core.Renewer
+	client   *scm.Client
+	addr     string
+	comments bool
+}
+
+func (s *service) Create(ctx context.Context, user *core.User, repo *core.Repository) error {
+	err := s.renew.Renew(ctx, user, false)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
+		Token:   user.Token,
+		Refresh: user.Refresh,
+		Expires: time.Unix(user.Expiry, 0),
+	})
+	hook := &scm.HookInput{
+		Name:   "drone",
+		Target: s.addr + "/hook",
+		Secret: repo.Signer,
+		Events: scm.HookEvents{
+			Branch:             true,
+			Deployment:         true,
+			PullRequest:        true,
+			PullRequestComment: s.comments,
+			Push:               true,
+			Tag:                true,
+		},
+	}
+	return replaceHook(ctx, s.client, repo.Slug, hook)
+}
+
+func (s *service) Delete(ctx context.Context, user *core.User, repo *core.Repository) error {
+	err := s.renew.Renew(ctx, user, false)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, scm.TokenKey{}, &scm.Token{
+		Token:   user.Token,
+		Refresh: user.Refresh,
+		Expires: time.Unix(user.Expiry, 0),
+	})
+	return deleteHook(ctx, s.client, repo.Slug, s.addr)
+}
+
+func replaceHook(ctx context.Context, client *scm.Client, repo string, hook *scm.HookInput) error {
+	if err := deleteHook(ctx, client, repo, hook.Target); err != nil {
+		return err
+	}
+	_, _, err := client.Repositories.CreateHook(ctx, repo, hook)
+	return err
+}
+
+func deleteHook(ctx context.Context, client *scm.Client, repo, target string) error {
+	u, _ := url.Parse(target)
+	h, err := findHook(ctx, client, repo, u.Host)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		return nil
+	}
+	_, err = client.Repositories.DeleteHook(ctx, repo, h.ID)
+	return err
+}
+
+func findHook(ctx context.Context, client *scm.Client, repo, host string) (*scm.Hook, error) {
+	hooks, _, err := client.Repositories.ListHooks(ctx, repo, scm.ListOptions{Size: 100})
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		u, err := url.Parse(hook.Target)
+		if err != nil {
+			continue
+		}
+		if u.Host == host {
+			return hook, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,96 @@
+package netrc
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/scm/driver/gitee"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/go-scm/scm"
+)
+
+var _ core.NetrcService = (*Service)(nil)
+
+// Service implements a netrc file generation service that
+// supports the Gitee driver and per repository clone
+// credentials.
+type Service struct {
+	client   *scm.Client
+	renewer  core.Renewer
+	creds    model.CredentialStore
+	private  bool
+	username string
+	password string
+}
+
+// New returns a new Netrc service.
+func New(
+	client *scm.Client,
+	renewer core.Renewer,
+	creds model.CredentialStore,
+	private bool,
+	username string,
+	password string,
+) core.NetrcService {
+	return &Service{
+		client:   client,
+		renewer:  renewer,
+		creds:    creds,
+		private:  private,
+		username: username,
+		password: password,
+	}
+}
+
+// Create creates a netrc file for the user and repository.
+func (s *Service) Create(ctx context.Context, user *core.User, repo *core.Repository) (*core.Netrc, error) {
+	// if the repository is public and private mode is disabled,
+	// authentication is not required.
+	if repo.Private == false && s.private == false {
+		return nil, nil
+	}
+
+	cred, _, err := s.creds.Find(ctx, repo.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	netrc := new(core.Netrc)
+	err = netrc.SetMachine(repo.HTTPURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if cred != nil && cred.Type == model.CredentialMachine {
+		netrc.Login = cred.Username
+		netrc.Password = cred.Password
+		return netrc, nil
+	}
+
+	if s.username != "" && s.password != "" {
+		netrc.Password = s.password
+		netrc.Login = s.username
+		return netrc, nil
+	}
+
+	// force refresh the authorization token to prevent
+	// it from expiring during pipeline execution.
+	err = s.renewer.Renew(ctx, user, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.client.Driver {
+	case scm.DriverGitlab, gitee.DriverGitee:
+		netrc.Login = "oauth2"
+		netrc.Password = user.Token
+	case scm.DriverBitbucket:
+		netrc.Login = "x-token-auth"
+		netrc.Password = user.Token
+	case scm.DriverGithub, scm.DriverGogs, scm.DriverGitea:
+		netrc.Password = "x-oauth-basic"
+		netrc.Login = user.Token
+	}
+	return netrc, nil
+}
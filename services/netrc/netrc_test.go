@@ -0,0 +1,99 @@
+package netrc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/pkg/scm/driver/gitee"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/go-scm/scm"
+)
+
+func TestNetrcGitee(t *testing.T) {
+	client := &scm.Client{Driver: gitee.DriverGitee}
+	service := New(client, noopRenewer{}, credStore{}, false, "", "")
+	got, err := service.Create(context.Background(),
+		&core.User{Token: "755bb80e5b"},
+		&core.Repository{Slug: "octocat/hello-world", Private: true, HTTPURL: "https://gitee.com/octocat/hello-world.git"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Machine != "gitee.com" || got.Login != "oauth2" || got.Password != "755bb80e5b" {
+		t.Errorf("Unexpected netrc %+v", got)
+	}
+}
+
+func TestNetrcMachineUser(t *testing.T) {
+	creds := credStore{
+		"octocat/hello-world": {Type: model.CredentialMachine, Username: "robot", Password: "correct-horse"},
+	}
+	service := New(&scm.Client{Driver: gitee.DriverGitee}, nil, creds, false, "", "")
+	got, err := service.Create(context.Background(),
+		&core.User{Token: "755bb80e5b"},
+		&core.Repository{Slug: "octocat/hello-world", Private: true, HTTPURL: "https://gitee.com/octocat/hello-world.git"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Login != "robot" || got.Password != "correct-horse" {
+		t.Errorf("Want machine user credentials, got %+v", got)
+	}
+}
+
+func TestNetrcDeployKey(t *testing.T) {
+	creds := credStore{
+		"octocat/hello-world": {Type: model.CredentialDeployKey},
+	}
+	service := New(&scm.Client{Driver: gitee.DriverGitee}, noopRenewer{}, creds, false, "", "")
+	got, err := service.Create(context.Background(),
+		&core.User{Token: "755bb80e5b"},
+		&core.Repository{Slug: "octocat/hello-world", Private: true, HTTPURL: "https://gitee.com/octocat/hello-world.git"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Login != "oauth2" {
+		t.Errorf("Want https credentials for stored deploy keys, got %+v", got)
+	}
+}
+
+func TestNetrcPublic(t *testing.T) {
+	service := New(&scm.Client{Driver: gitee.DriverGitee}, nil, errStore{}, false, "", "")
+	got, err := service.Create(context.Background(),
+		&core.User{Token: "755bb80e5b"},
+		&core.Repository{Slug: "octocat/hello-world", HTTPURL: "https://gitee.com/octocat/hello-world.git"},
+	)
+	if err != nil {
+		t.Errorf("Want clone credentials not queried for public repositories, got %s", err)
+	}
+	if got != nil {
+		t.Errorf("Want no netrc for public repositories, got %+v", got)
+	}
+}
+
+type noopRenewer struct{}
+
+func (noopRenewer) Renew(context.Context, *core.User, bool) error { return nil }
+
+type credStore map[string]*model.Credential
+
+func (s credStore) Find(ctx context.Context, slug string) (*model.Credential, bool, error) {
+	cred, ok := s[slug]
+	return cred, ok, nil
+}
+
+func (s credStore) Put(context.Context, *model.Credential) error { return nil }
+
+func (s credStore) Delete(context.Context, string) error { return nil }
+
+type errStore struct {
+	credStore
+}
+
+func (errStore) Find(context.Context, string) (*model.Credential, bool, error) {
+	return nil, false, errors.New("not implemented")
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/99designs/httpsignatures-go"
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/plugin/webhook"
+)
+
+// required http headers
+var headers = []string{
+	"date",
+	"digest",
+}
+
+var signer = httpsignatures.NewSigner(
+	httpsignatures.AlgorithmHmacSha256,
+	headers...,
+)
+
+// New returns a new WebhookSender. The drone webhooks are sent
+// by the drone webhook sender, and the extend api events are
+// sent to the same endpoints, filtered and signed the same way.
+func New(config webhook.Config) model.WebhookSender {
+	return &sender{
+		WebhookSender: webhook.New(config),
+		Events:        config.Events,
+		Endpoints:     config.Endpoint,
+		Secret:        config.Secret,
+		System:        config.System,
+	}
+}
+
+type payload struct {
+	Event  string       `json:"event"`
+	Action string       `json:"action"`
+	Data   interface{}  `json:"data"`
+	System *core.System `json:"system,omitempty"`
+}
+
+type sender struct {
+	core.WebhookSender
+
+	Client    *http.Client
+	Events    []string
+	Endpoints []string
+	Secret    string
+	System    *core.System
+}
+
+// SendEvent sends the JSON encoded event to the global HTTP
+// endpoints.
+func (s *sender) SendEvent(ctx context.Context, event, action string, data interface{}) error {
+	if len(s.Endpoints) == 0 {
+		return nil
+	}
+	if !s.match(event, action) {
+		return nil
+	}
+	out, err := json.Marshal(&payload{
+		Event:  event,
+		Action: action,
+		Data:   data,
+		System: s.System,
+	})
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range s.Endpoints {
+		err := s.send(ctx, endpoint, event, out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sender) send(ctx context.Context, endpoint, event string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Add("X-Drone-Event", event)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Digest", "SHA-256="+digest(data))
+	req.Header.Add("Date", time.Now().UTC().Format(http.TimeFormat))
+	err = signer.SignRequest("hmac-key", s.Secret, req)
+	if err != nil {
+		return err
+	}
+	res, err := s.client().Do(req)
+	if res != nil {
+		res.Body.Close()
+	}
+	return err
+}
+
+func (s *sender) match(event, action string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	name := event
+	if action != "" {
+		name = event + ":" + action
+	}
+	for _, pattern := range s.Events {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sender) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+func digest(data []byte) string {
+	h := sha256.New()
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
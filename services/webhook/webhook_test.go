@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/plugin/webhook"
+	"github.com/oars-sigs/drone/model"
+)
+
+func TestSendEvent(t *testing.T) {
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Signature") == "" {
+			t.Errorf("Want signed webhook request")
+		}
+		in := new(payload)
+		json.NewDecoder(r.Body).Decode(in)
+		events = append(events, in.Event+":"+in.Action)
+	}))
+	defer srv.Close()
+
+	sender := New(webhook.Config{
+		Endpoint: []string{srv.URL},
+		Secret:   "correct-horse-battery-staple",
+		Events:   []string{"pipeline:*"},
+	})
+	ctx := context.Background()
+	sender.SendEvent(ctx, model.WebhookEventPipeline, core.WebhookActionUpdated, &model.PipelineHook{Ref: "default"})
+	sender.SendEvent(ctx, model.WebhookEventTemplate, core.WebhookActionUpdated, &model.TemplateHook{Name: "golang"})
+
+	if len(events) != 1 || events[0] != "pipeline:updated" {
+		t.Errorf("Want only the pipeline:updated event, got %v", events)
+	}
+}
@@ -0,0 +1,77 @@
+package approvals
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+func New(db *db.DB) model.ApprovalStore {
+	return &approvalStore{db: db}
+}
+
+type approvalStore struct {
+	db *db.DB
+}
+
+//List returns the approvals of the build.
+func (s *approvalStore) List(ctx context.Context, buildID int64) ([]*model.Approval, error) {
+	var out []*model.Approval
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params := toParams(&model.Approval{BuildID: buildID})
+		query, args, err := binder.BindNamed(queryByBuild, params)
+		if err != nil {
+			return err
+		}
+		rows, err := queryer.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		out, err = scanRows(rows)
+		return err
+	})
+	return out, err
+}
+
+//Create persists a new approval to the datastore.
+func (s *approvalStore) Create(ctx context.Context, approval *model.Approval) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params := toParams(approval)
+		stmt, args, err := binder.BindNamed(stmtInsert, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
+		return err
+	})
+}
+
+const queryByBuild = `
+SELECT
+ approval_build_id
+,approval_slug
+,approval_number
+,approval_user
+,approval_created
+FROM tpipe_approvals
+WHERE approval_build_id=:approval_build_id
+ORDER BY approval_created ASC
+`
+
+const stmtInsert = `
+INSERT INTO tpipe_approvals (
+ approval_build_id
+,approval_slug
+,approval_number
+,approval_user
+,approval_created
+) VALUES (
+ :approval_build_id
+,:approval_slug
+,:approval_number
+,:approval_user
+,:approval_created
+)
+`
@@ -0,0 +1,50 @@
+package approvals
+
+import (
+	"database/sql"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// helper function converts the Approval structure to a set
+// of named query parameters.
+func toParams(a *model.Approval) map[string]interface{} {
+	return map[string]interface{}{
+		"approval_build_id": a.BuildID,
+		"approval_slug":     a.Slug,
+		"approval_number":   a.Number,
+		"approval_user":     a.User,
+		"approval_created":  a.Created,
+	}
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRow(scanner db.Scanner, dest *model.Approval) error {
+	return scanner.Scan(
+		&dest.BuildID,
+		&dest.Slug,
+		&dest.Number,
+		&dest.User,
+		&dest.Created,
+	)
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRows(rows *sql.Rows) ([]*model.Approval, error) {
+	defer rows.Close()
+
+	approvals := []*model.Approval{}
+	for rows.Next() {
+		approval := new(model.Approval)
+		err := scanRow(rows, approval)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
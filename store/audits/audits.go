@@ -0,0 +1,104 @@
+package audits
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// defaultLimit and maxLimit are the default and maximum
+// number of entries listed.
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+func New(db *db.DB) model.AuditStore {
+	return &auditStore{db: db}
+}
+
+type auditStore struct {
+	db *db.DB
+}
+
+//List returns the audit log entries matching the filter,
+//most recent first.
+func (s *auditStore) List(ctx context.Context, filter model.AuditFilter) ([]*model.Audit, error) {
+	var out []*model.Audit
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params := toFilterParams(filter)
+		query, args, err := binder.BindNamed(queryFilter, params)
+		if err != nil {
+			return err
+		}
+		rows, err := queryer.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		out, err = scanRows(rows)
+		return err
+	})
+	return out, err
+}
+
+//Create persists a new audit log entry to the datastore.
+func (s *auditStore) Create(ctx context.Context, audit *model.Audit) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params := toParams(audit)
+		stmt, args, err := binder.BindNamed(stmtInsert, params)
+		if err != nil {
+			return err
+		}
+		res, err := execer.Exec(stmt, args...)
+		if err != nil {
+			return err
+		}
+		audit.ID, _ = res.LastInsertId()
+		return nil
+	})
+}
+
+const queryFilter = `
+SELECT
+ audit_id
+,audit_actor
+,audit_action
+,audit_target
+,audit_before
+,audit_after
+,audit_source_ip
+,audit_status
+,audit_created
+FROM tpipe_audits
+WHERE (:filter_actor = '' OR audit_actor = :filter_actor)
+  AND (:filter_action = '' OR audit_action = :filter_action)
+  AND (:filter_target = '' OR audit_target LIKE :filter_target_prefix ESCAPE '!')
+  AND (:filter_since = 0 OR audit_created >= :filter_since)
+  AND (:filter_until = 0 OR audit_created <= :filter_until)
+ORDER BY audit_id DESC
+LIMIT :filter_limit OFFSET :filter_offset
+`
+
+const stmtInsert = `
+INSERT INTO tpipe_audits (
+ audit_actor
+,audit_action
+,audit_target
+,audit_before
+,audit_after
+,audit_source_ip
+,audit_status
+,audit_created
+) VALUES (
+ :audit_actor
+,:audit_action
+,:audit_target
+,:audit_before
+,:audit_after
+,:audit_source_ip
+,:audit_status
+,:audit_created
+)
+`
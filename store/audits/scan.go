@@ -0,0 +1,83 @@
+package audits
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// helper function converts the Audit structure to a set
+// of named query parameters.
+func toParams(a *model.Audit) map[string]interface{} {
+	return map[string]interface{}{
+		"audit_actor":     a.Actor,
+		"audit_action":    a.Action,
+		"audit_target":    a.Target,
+		"audit_before":    a.Before,
+		"audit_after":     a.After,
+		"audit_source_ip": a.SourceIP,
+		"audit_status":    a.Status,
+		"audit_created":   a.Created,
+	}
+}
+
+// helper function converts the AuditFilter structure to a set
+// of named query parameters.
+func toFilterParams(f model.AuditFilter) map[string]interface{} {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	// escape the like wildcards, so the target only matches
+	// as a prefix.
+	prefix := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(f.Target)
+	return map[string]interface{}{
+		"filter_actor":         f.Actor,
+		"filter_action":        f.Action,
+		"filter_target":        f.Target,
+		"filter_target_prefix": prefix + "%",
+		"filter_since":         f.Since,
+		"filter_until":         f.Until,
+		"filter_limit":         limit,
+		"filter_offset":        f.Offset,
+	}
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRow(scanner db.Scanner, dest *model.Audit) error {
+	return scanner.Scan(
+		&dest.ID,
+		&dest.Actor,
+		&dest.Action,
+		&dest.Target,
+		&dest.Before,
+		&dest.After,
+		&dest.SourceIP,
+		&dest.Status,
+		&dest.Created,
+	)
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRows(rows *sql.Rows) ([]*model.Audit, error) {
+	defer rows.Close()
+
+	audits := []*model.Audit{}
+	for rows.Next() {
+		audit := new(model.Audit)
+		err := scanRow(rows, audit)
+		if err != nil {
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+	return audits, nil
+}
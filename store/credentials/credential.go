@@ -0,0 +1,135 @@
+package credentials
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// New returns a new CredentialStore. The machine user password
+// is encrypted if the encrypter has a key.
+func New(db *db.DB, enc encrypt.Encrypter) model.CredentialStore {
+	return &credentialStore{db: db, enc: enc}
+}
+
+type credentialStore struct {
+	db  *db.DB
+	enc encrypt.Encrypter
+}
+
+//Find returns the clone credential of the repository.
+func (s *credentialStore) Find(ctx context.Context, slug string) (*model.Credential, bool, error) {
+	out := &model.Credential{Slug: slug}
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params := map[string]interface{}{"credential_slug": slug}
+		query, args, err := binder.BindNamed(queryBySlug, params)
+		if err != nil {
+			return err
+		}
+		row := queryer.QueryRow(query, args...)
+		return scanRow(s.enc, row, out)
+	})
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+//Put persists the clone credential of the repository.
+func (s *credentialStore) Put(ctx context.Context, cred *model.Credential) error {
+	_, isExist, err := s.Find(ctx, cred.Slug)
+	if err != nil {
+		return err
+	}
+	cred.Updated = time.Now().Unix()
+	stmt := stmtUpdate
+	if !isExist {
+		cred.Created = cred.Updated
+		stmt = stmtInsert
+	}
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params, err := toParams(s.enc, cred)
+		if err != nil {
+			return err
+		}
+		stmt, args, err := binder.BindNamed(stmt, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
+		return err
+	})
+}
+
+//Delete removes the clone credential of the repository.
+func (s *credentialStore) Delete(ctx context.Context, slug string) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params := map[string]interface{}{"credential_slug": slug}
+		stmt, args, err := binder.BindNamed(stmtDelete, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
+		return err
+	})
+}
+
+const queryBySlug = `
+SELECT
+ credential_slug
+,credential_type
+,credential_username
+,credential_password
+,credential_key_id
+,credential_public_key
+,credential_created
+,credential_updated
+FROM tpipe_credentials
+WHERE credential_slug=:credential_slug
+`
+
+const stmtInsert = `
+INSERT INTO tpipe_credentials (
+ credential_slug
+,credential_type
+,credential_username
+,credential_password
+,credential_key_id
+,credential_public_key
+,credential_created
+,credential_updated
+) VALUES (
+ :credential_slug
+,:credential_type
+,:credential_username
+,:credential_password
+,:credential_key_id
+,:credential_public_key
+,:credential_created
+,:credential_updated
+)
+`
+
+const stmtUpdate = `
+UPDATE tpipe_credentials SET
+ credential_type=:credential_type
+,credential_username=:credential_username
+,credential_password=:credential_password
+,credential_key_id=:credential_key_id
+,credential_public_key=:credential_public_key
+,credential_updated=:credential_updated
+WHERE credential_slug=:credential_slug
+`
+
+const stmtDelete = `
+DELETE FROM tpipe_credentials
+WHERE credential_slug=:credential_slug
+`
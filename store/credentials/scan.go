@@ -0,0 +1,47 @@
+package credentials
+
+import (
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// helper function converts the Credential structure to a set
+// of named query parameters.
+func toParams(enc encrypt.Encrypter, c *model.Credential) (map[string]interface{}, error) {
+	password, err := encrypt.EncryptText(enc, c.Password)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"credential_slug":       c.Slug,
+		"credential_type":       c.Type,
+		"credential_username":   c.Username,
+		"credential_password":   password,
+		"credential_key_id":     c.KeyID,
+		"credential_public_key": c.PublicKey,
+		"credential_created":    c.Created,
+		"credential_updated":    c.Updated,
+	}, nil
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRow(enc encrypt.Encrypter, scanner db.Scanner, dest *model.Credential) error {
+	err := scanner.Scan(
+		&dest.Slug,
+		&dest.Type,
+		&dest.Username,
+		&dest.Password,
+		&dest.KeyID,
+		&dest.PublicKey,
+		&dest.Created,
+		&dest.Updated,
+	)
+	if err != nil {
+		return err
+	}
+	dest.Password, err = encrypt.DecryptText(enc, dest.Password)
+	return err
+}
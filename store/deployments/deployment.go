@@ -0,0 +1,85 @@
+package deployments
+
+import (
+	"context"
+
+	"github.com/oars-sigs/drone/model"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/store/shared/db"
+)
+
+func New(db *db.DB) model.DeploymentStore {
+	return &deploymentStore{db: db}
+}
+
+type deploymentStore struct {
+	db *db.DB
+}
+
+//List returns the promote and rollback builds of the repository
+//to the target environment, most recent first.
+func (s *deploymentStore) List(ctx context.Context, repoID int64, target string) ([]*core.Build, error) {
+	var out []*core.Build
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params := map[string]interface{}{
+			"build_repo_id":  repoID,
+			"build_deploy":   target,
+			"event_promote":  core.EventPromote,
+			"event_rollback": core.EventRollback,
+		}
+		query, args, err := binder.BindNamed(queryRepo, params)
+		if err != nil {
+			return err
+		}
+		rows, err := queryer.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		out, err = scanRows(rows)
+		return err
+	})
+	return out, err
+}
+
+const queryRepo = `
+SELECT
+ build_id
+,build_repo_id
+,build_trigger
+,build_number
+,build_parent
+,build_status
+,build_error
+,build_event
+,build_action
+,build_link
+,build_timestamp
+,build_title
+,build_message
+,build_before
+,build_after
+,build_ref
+,build_source_repo
+,build_source
+,build_target
+,build_author
+,build_author_name
+,build_author_email
+,build_author_avatar
+,build_sender
+,build_params
+,build_cron
+,build_deploy
+,build_deploy_id
+,build_started
+,build_finished
+,build_created
+,build_updated
+,build_version
+FROM builds
+WHERE build_repo_id = :build_repo_id
+  AND build_event IN (:event_promote, :event_rollback)
+  AND (:build_deploy = '' OR build_deploy = :build_deploy)
+ORDER BY build_id DESC
+`
@@ -0,0 +1,72 @@
+package deployments
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/store/shared/db"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object. The columns match the
+// drone build store.
+func scanRow(scanner db.Scanner, dest *core.Build) error {
+	paramsJSON := types.JSONText{}
+	err := scanner.Scan(
+		&dest.ID,
+		&dest.RepoID,
+		&dest.Trigger,
+		&dest.Number,
+		&dest.Parent,
+		&dest.Status,
+		&dest.Error,
+		&dest.Event,
+		&dest.Action,
+		&dest.Link,
+		&dest.Timestamp,
+		&dest.Title,
+		&dest.Message,
+		&dest.Before,
+		&dest.After,
+		&dest.Ref,
+		&dest.Fork,
+		&dest.Source,
+		&dest.Target,
+		&dest.Author,
+		&dest.AuthorName,
+		&dest.AuthorEmail,
+		&dest.AuthorAvatar,
+		&dest.Sender,
+		&paramsJSON,
+		&dest.Cron,
+		&dest.Deploy,
+		&dest.DeployID,
+		&dest.Started,
+		&dest.Finished,
+		&dest.Created,
+		&dest.Updated,
+		&dest.Version,
+	)
+	dest.Params = map[string]string{}
+	json.Unmarshal(paramsJSON, &dest.Params)
+	return err
+}
+
+// helper function scans the sql.Rows and copies the column
+// values to the destination objects.
+func scanRows(rows *sql.Rows) ([]*core.Build, error) {
+	defer rows.Close()
+
+	builds := []*core.Build{}
+	for rows.Next() {
+		build := new(core.Build)
+		err := scanRow(rows, build)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, build)
+	}
+	return builds, nil
+}
@@ -0,0 +1,210 @@
+package overrides
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/store/shared/db"
+)
+
+// New returns a new OverrideStore. The override content is
+// encrypted if the encrypter has a key.
+func New(db *db.DB, enc encrypt.Encrypter) model.OverrideStore {
+	return &overrideStore{db: db, enc: enc}
+}
+
+type overrideStore struct {
+	db  *db.DB
+	enc encrypt.Encrypter
+}
+
+//GetOverride returns the pipeline override of the build from
+//the datastore.
+func (s *overrideStore) GetOverride(ctx context.Context, buildID int64) (*model.Override, bool, error) {
+	out := &model.Override{BuildID: buildID}
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params := map[string]interface{}{"override_build_id": buildID}
+		query, args, err := binder.BindNamed(queryByBuild, params)
+		if err != nil {
+			return err
+		}
+		row := queryer.QueryRow(query, args...)
+		return scanRow(s.enc, row, out)
+	})
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+//CreateOverride persists a new pipeline override to the datastore.
+func (s *overrideStore) CreateOverride(ctx context.Context, override *model.Override) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params, err := toParams(s.enc, override)
+		if err != nil {
+			return err
+		}
+		stmt, args, err := binder.BindNamed(stmtInsert, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
+		return err
+	})
+}
+
+//LinkOverride links the pipeline override to the build it was
+//triggered for.
+func (s *overrideStore) LinkOverride(ctx context.Context, override *model.Override) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params := map[string]interface{}{
+			"override_uuid":     override.UUID,
+			"override_build_id": override.BuildID,
+			"override_number":   override.Number,
+		}
+		stmt, args, err := binder.BindNamed(stmtLink, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
+		return err
+	})
+}
+
+//Find returns the pipeline override of the build being
+//triggered, which is passed in the context. Promotions and
+//rollbacks, which are created by drone with the number of the
+//build they deploy as the parent, use the override of the
+//closest parent that has one. The override is only served to
+//builds of the repository it was created for.
+func (s *overrideStore) Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error) {
+	override, ok := model.OverrideFrom(ctx)
+	if !ok {
+		var err error
+		override, ok, err = s.findParent(ctx, r.Repo, r.Build.Parent)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+	if override.Slug != r.Repo.Slug {
+		return nil, errors.New(r.Repo.Slug + " pipeline override not found")
+	}
+	return &core.Config{
+		Kind: "pipeline",
+		Data: override.Content,
+	}, nil
+}
+
+// helper function returns the pipeline override of the parent
+// build, following the parents of the builds without one.
+func (s *overrideStore) findParent(ctx context.Context, repo *core.Repository, number int64) (*model.Override, bool, error) {
+	for number != 0 {
+		out := new(model.Override)
+		var parent int64
+		err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+			params := map[string]interface{}{
+				"override_slug":   repo.Slug,
+				"override_number": number,
+				"build_repo_id":   repo.ID,
+				"build_number":    number,
+			}
+			query, args, err := binder.BindNamed(queryByNumber, params)
+			if err != nil {
+				return err
+			}
+			err = scanRow(s.enc, queryer.QueryRow(query, args...), out)
+			if err != sql.ErrNoRows {
+				return err
+			}
+			query, args, err = binder.BindNamed(queryParent, params)
+			if err != nil {
+				return err
+			}
+			return queryer.QueryRow(query, args...).Scan(&parent)
+		})
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if out.UUID != "" {
+			return out, true, nil
+		}
+		// the parent of a build is always an earlier build.
+		if parent >= number {
+			return nil, false, nil
+		}
+		number = parent
+	}
+	return nil, false, nil
+}
+
+const queryByBuild = `
+SELECT
+ override_uuid
+,override_slug
+,override_build_id
+,override_number
+,override_content
+,override_creator
+,override_created
+FROM tpipe_overrides
+WHERE override_build_id=:override_build_id
+`
+
+const queryByNumber = `
+SELECT
+ override_uuid
+,override_slug
+,override_build_id
+,override_number
+,override_content
+,override_creator
+,override_created
+FROM tpipe_overrides
+WHERE override_slug=:override_slug
+AND override_number=:override_number
+`
+
+const queryParent = `
+SELECT build_parent
+FROM builds
+WHERE build_repo_id=:build_repo_id
+AND build_number=:build_number
+`
+
+const stmtLink = `
+UPDATE tpipe_overrides SET
+ override_build_id=:override_build_id
+,override_number=:override_number
+WHERE override_uuid=:override_uuid
+`
+
+const stmtInsert = `
+INSERT INTO tpipe_overrides (
+ override_uuid
+,override_slug
+,override_build_id
+,override_number
+,override_content
+,override_creator
+,override_created
+) VALUES (
+ :override_uuid
+,:override_slug
+,:override_build_id
+,:override_number
+,:override_content
+,:override_creator
+,:override_created
+)
+`
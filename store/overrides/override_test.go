@@ -0,0 +1,168 @@
+package overrides
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oars-sigs/drone/model"
+	extdb "github.com/oars-sigs/drone/store/shared/db"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/store/build"
+	"github.com/drone/drone/store/shared/db"
+	"github.com/drone/drone/store/shared/encrypt"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestFind(t *testing.T) {
+	store := &overrideStore{}
+	args := &core.ConfigArgs{
+		Repo:  &core.Repository{Slug: "octocat/hello-world"},
+		Build: &core.Build{Params: map[string]string{"DRONE_PIPELINE_OVERRIDE": "3e5a1b9c"}},
+	}
+
+	// the build parameters cannot reference an override.
+	config, err := store.Find(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		t.Errorf("Want no override without the context, got %+v", config)
+	}
+
+	override := &model.Override{Slug: "octocat/hello-world", Content: "kind: pipeline"}
+	ctx := model.WithOverride(context.Background(), override)
+	config, err = store.Find(ctx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config == nil || config.Data != "kind: pipeline" {
+		t.Errorf("Want the override of the build, got %+v", config)
+	}
+
+	args.Repo = &core.Repository{Slug: "octocat/spoon-knife"}
+	if _, err := store.Find(ctx, args); err == nil {
+		t.Errorf("Want error for an override of another repository")
+	}
+}
+
+func TestGetOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := extdb.Connect("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	enc, err := encrypt.New("fb4b4d6267c8a5ce8231f8b186dbca92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(conn, enc)
+
+	err = store.CreateOverride(context.Background(), &model.Override{
+		UUID:    "3e5a1b9c",
+		Slug:    "octocat/hello-world",
+		BuildID: 42,
+		Number:  7,
+		Content: "kind: pipeline",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content string
+	conn.View(func(queryer db.Queryer, binder db.Binder) error {
+		return queryer.QueryRow("SELECT override_content FROM tpipe_overrides").Scan(&content)
+	})
+	if content == "kind: pipeline" {
+		t.Errorf("Want encrypted override content")
+	}
+
+	override, isExist, err := store.GetOverride(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isExist || override.UUID != "3e5a1b9c" || override.Content != "kind: pipeline" {
+		t.Errorf("Want the override of build 42, got %+v", override)
+	}
+	_, isExist, err = store.GetOverride(context.Background(), 43)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isExist {
+		t.Errorf("Want no override for build 43")
+	}
+}
+
+func TestFind_Parent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := extdb.Connect("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	enc, err := encrypt.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(conn, enc)
+	ctx := context.Background()
+
+	// the override is stored before the build is created.
+	override := &model.Override{UUID: "3e5a1b9c", Slug: "octocat/hello-world", Content: "kind: pipeline"}
+	if err := store.CreateOverride(ctx, override); err != nil {
+		t.Fatal(err)
+	}
+	builds := build.New(conn)
+	for _, b := range []*core.Build{
+		{RepoID: 1, Number: 7, Event: core.EventPush},
+		{RepoID: 1, Number: 8, Parent: 7, Event: core.EventPromote},
+	} {
+		if err := builds.Create(ctx, b, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	override.BuildID, override.Number = 1, 7
+	if err := store.LinkOverride(ctx, override); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &core.Repository{ID: 1, Slug: "octocat/hello-world"}
+	tests := []struct {
+		parent int64
+		want   string
+	}{
+		{7, "kind: pipeline"},
+		// the override of the promoted build is followed.
+		{8, "kind: pipeline"},
+		{0, ""},
+		{9, ""},
+	}
+	for _, test := range tests {
+		args := &core.ConfigArgs{Repo: repo, Build: &core.Build{Parent: test.parent}}
+		config, err := store.Find(ctx, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if config != nil {
+			got = config.Data
+		}
+		if got != test.want {
+			t.Errorf("Want override %q for parent %d, got %q", test.want, test.parent, got)
+		}
+	}
+}
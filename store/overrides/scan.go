@@ -0,0 +1,45 @@
+package overrides
+
+import (
+	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
+
+	"github.com/drone/drone/store/shared/db"
+)
+
+// helper function converts the Override structure to a set
+// of named query parameters.
+func toParams(enc encrypt.Encrypter, o *model.Override) (map[string]interface{}, error) {
+	content, err := encrypt.EncryptText(enc, o.Content)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"override_uuid":     o.UUID,
+		"override_slug":     o.Slug,
+		"override_build_id": o.BuildID,
+		"override_number":   o.Number,
+		"override_content":  content,
+		"override_creator":  o.Creator,
+		"override_created":  o.Created,
+	}, nil
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRow(enc encrypt.Encrypter, scanner db.Scanner, dest *model.Override) error {
+	err := scanner.Scan(
+		&dest.UUID,
+		&dest.Slug,
+		&dest.BuildID,
+		&dest.Number,
+		&dest.Content,
+		&dest.Creator,
+		&dest.Created,
+	)
+	if err != nil {
+		return err
+	}
+	dest.Content, err = encrypt.DecryptText(enc, dest.Content)
+	return err
+}
@@ -4,54 +4,89 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
 
 	"github.com/drone/drone/core"
 	"github.com/drone/drone/store/shared/db"
 )
 
+// errNoRepository is returned when a pipeline is saved without
+// a repository ID.
+var errNoRepository = errors.New("pipelines: pipeline has no repository")
+
 type pipelineStore struct {
-	db *db.DB
+	db  *db.DB
+	enc encrypt.Encrypter
 }
 
-func New(db *db.DB) model.PipelineStore {
-	return &pipelineStore{db: db}
+// New returns a new PipelineStore. The pipeline content is
+// encrypted if the encrypter has a key.
+func New(db *db.DB, enc encrypt.Encrypter) model.PipelineStore {
+	return &pipelineStore{db: db, enc: enc}
 }
 
-// Get returns a of pipeline from the datastore
-func (s *pipelineStore) GetPipeline(ctx context.Context, slug, ref, configPath string) (*model.Pipeline, bool, error) {
-	out := &model.Pipeline{
-		Slug: slug,
-		Ref:  ref,
+// GetPipeline returns the pipeline of the repository for the
+// git reference and config path. An empty config path selects
+// the default .drone.yml. The pipeline is looked up by
+// repository ID, so it survives repository renames.
+func (s *pipelineStore) GetPipeline(ctx context.Context, repo *core.Repository, ref, configPath string) (*model.Pipeline, bool, error) {
+	pipe, err := s.findRepo(ctx, repo, ref, configPath)
+	if err != nil || pipe == nil {
+		return nil, false, err
+	}
+	return pipe, true, nil
+}
+
+// FindPipeline returns the pipeline for the git reference,
+// falling back to the default pipeline of the repository. If
+// neither exists, nil is returned.
+func (s *pipelineStore) FindPipeline(ctx context.Context, repo *core.Repository, ref string) (*model.Pipeline, error) {
+	if ref != "" {
+		pipe, isExist, err := s.GetPipeline(ctx, repo, ref, "")
+		if err != nil || isExist {
+			return pipe, err
+		}
 	}
+	pipe, isExist, err := s.GetPipeline(ctx, repo, "default", "")
+	if err != nil || !isExist {
+		return nil, err
+	}
+	return pipe, nil
+}
+
+func (s *pipelineStore) FindPipelineBySlug(ctx context.Context, slug string) ([]*model.Pipeline, error) {
+	var out []*model.Pipeline
 	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
-		params := toParams(out)
-		query, args, err := binder.BindNamed(queryBySlugRef, params)
+		params, err := toParams(s.enc, &model.Pipeline{Slug: slug})
 		if err != nil {
 			return err
 		}
-		row := queryer.QueryRow(query, args...)
+		query, args, err := binder.BindNamed(queryBySlug, params)
 		if err != nil {
 			return err
 		}
-
-		err = scanRow(row, out)
+		rows, err := queryer.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		out, err = scanRows(s.enc, rows)
 		return err
 	})
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, false, nil
-		}
-	}
-	return out, true, err
+	return out, err
 }
 
-func (s *pipelineStore) FindPipelineBySlug(ctx context.Context, slug string) ([]*model.Pipeline, error) {
+// ListByTemplate returns the pipelines derived from the template.
+func (s *pipelineStore) ListByTemplate(ctx context.Context, template string) ([]*model.Pipeline, error) {
 	var out []*model.Pipeline
 	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
-		params := toParams(&model.Pipeline{Slug: slug})
-		query, args, err := binder.BindNamed(queryBySlug, params)
+		params, err := toParams(s.enc, &model.Pipeline{Template: template})
+		if err != nil {
+			return err
+		}
+		query, args, err := binder.BindNamed(queryByTemplate, params)
 		if err != nil {
 			return err
 		}
@@ -59,23 +94,33 @@ func (s *pipelineStore) FindPipelineBySlug(ctx context.Context, slug string) ([]
 		if err != nil {
 			return err
 		}
-		out, err = scanRows(rows)
+		out, err = scanRows(s.enc, rows)
 		return err
 	})
 	return out, err
 }
 
+// CreatePipeline creates the pipeline with the same upsert as
+// SavePipeline, so concurrent creates cannot create duplicate
+// pipelines.
 func (s *pipelineStore) CreatePipeline(ctx context.Context, pipe *model.Pipeline) error {
-	_, isExist, err := s.GetPipeline(ctx, pipe.Slug, pipe.Ref, "")
+	return s.SavePipeline(ctx, pipe)
+}
+
+func (s *pipelineStore) UpdatePipeline(ctx context.Context, pipe *model.Pipeline) error {
+	// a revision is only recorded if the content changed,
+	// not when other fields (e.g. params) are updated.
+	prev, err := s.FindRevision(ctx, pipe.RepoID, pipe.Ref, time.Now().Unix())
 	if err != nil {
 		return err
 	}
-	if isExist {
-		return errors.New("pipeline has existed")
-	}
+	changed := prev == nil || prev.Ref != pipe.Ref || prev.Content != pipe.Content
 	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
-		params := toParams(pipe)
-		stmt, args, err := binder.BindNamed(stmtInsert, params)
+		params, err := toParams(s.enc, pipe)
+		if err != nil {
+			return err
+		}
+		stmt, args, err := binder.BindNamed(stmtUpdate, params)
 		if err != nil {
 			return err
 		}
@@ -83,14 +128,37 @@ func (s *pipelineStore) CreatePipeline(ctx context.Context, pipe *model.Pipeline
 		if err != nil {
 			return err
 		}
-		return err
+		if !changed {
+			return nil
+		}
+		return insertRevision(s.enc, execer, binder, pipe)
 	})
 }
 
-func (s *pipelineStore) UpdatePipeline(ctx context.Context, pipe *model.Pipeline) error {
+// SavePipeline creates the pipeline or, if a pipeline exists
+// for the repository ID, ref and config path, updates its
+// content, template, sync status and slug. The upsert is a
+// single statement, so concurrent saves cannot create duplicate
+// pipelines.
+func (s *pipelineStore) SavePipeline(ctx context.Context, pipe *model.Pipeline) error {
+	if pipe.RepoID == 0 {
+		return errNoRepository
+	}
+	prev, err := s.FindRevision(ctx, pipe.RepoID, pipe.Ref, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	changed := prev == nil || prev.Ref != pipe.Ref || prev.Content != pipe.Content
 	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
-		params := toParams(pipe)
-		stmt, args, err := binder.BindNamed(stmtUpdate, params)
+		params, err := toParams(s.enc, pipe)
+		if err != nil {
+			return err
+		}
+		stmt := stmtUpsert
+		if s.db.Driver() == db.Mysql {
+			stmt = stmtUpsertMysql
+		}
+		stmt, args, err := binder.BindNamed(stmt, params)
 		if err != nil {
 			return err
 		}
@@ -98,44 +166,216 @@ func (s *pipelineStore) UpdatePipeline(ctx context.Context, pipe *model.Pipeline
 		if err != nil {
 			return err
 		}
+		if !changed {
+			return nil
+		}
+		return insertRevision(s.enc, execer, binder, pipe)
+	})
+}
+
+// DeletePipeline deletes the pipeline. The revisions of the
+// pipeline are kept, so earlier builds can still be promoted
+// or rolled back.
+func (s *pipelineStore) DeletePipeline(ctx context.Context, pipe *model.Pipeline) error {
+	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
+		params, err := toParams(s.enc, pipe)
+		if err != nil {
+			return err
+		}
+		stmt, args, err := binder.BindNamed(stmtDelete, params)
+		if err != nil {
+			return err
+		}
+		_, err = execer.Exec(stmt, args...)
 		return err
 	})
 }
 
-func (s *pipelineStore) Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error) {
-	pipe, isExist, err := s.GetPipeline(ctx, r.Repo.Slug, r.Build.Ref, "")
+// FindRevision returns the pipeline revision for the git
+// reference that was current at the given time, falling back
+// to the default pipeline of the repository. If no revision
+// exists, nil is returned.
+func (s *pipelineStore) FindRevision(ctx context.Context, repoID int64, ref string, before int64) (*model.Revision, error) {
+	for _, ref := range []string{ref, "default"} {
+		out := &model.Revision{RepoID: repoID, Ref: ref, Created: before}
+		err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+			params, err := toRevisionParams(s.enc, out)
+			if err != nil {
+				return err
+			}
+			query, args, err := binder.BindNamed(queryRevision, params)
+			if err != nil {
+				return err
+			}
+			row := queryer.QueryRow(query, args...)
+			return scanRevision(s.enc, row, out)
+		})
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+// GetRevision returns the pipeline revision of the repository
+// by id. If the revision does not exist, nil is returned.
+func (s *pipelineStore) GetRevision(ctx context.Context, repoID int64, id int64) (*model.Revision, error) {
+	out := &model.Revision{ID: id, RepoID: repoID}
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params, err := toRevisionParams(s.enc, out)
+		if err != nil {
+			return err
+		}
+		query, args, err := binder.BindNamed(queryRevisionID, params)
+		if err != nil {
+			return err
+		}
+		row := queryer.QueryRow(query, args...)
+		return scanRevision(s.enc, row, out)
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	if isExist {
-		return &core.Config{
-			Kind: "pipeline",
-			Data: pipe.Content,
-		}, nil
+	return out, nil
+}
+
+// helper function records the current content of the
+// pipeline as a new revision.
+func insertRevision(enc encrypt.Encrypter, execer db.Execer, binder db.Binder, pipe *model.Pipeline) error {
+	params, err := toRevisionParams(enc, &model.Revision{
+		RepoID:  pipe.RepoID,
+		Slug:    pipe.Slug,
+		Ref:     pipe.Ref,
+		Content: pipe.Content,
+		Created: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
 	}
-	pipe, isExist, err = s.GetPipeline(ctx, r.Repo.Slug, "default", "")
+	stmt, args, err := binder.BindNamed(stmtInsertRevision, params)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if isExist {
-		return &core.Config{
-			Kind: "pipeline",
-			Data: pipe.Content,
-		}, nil
+	_, err = execer.Exec(stmt, args...)
+	return err
+}
+
+// Find returns the pipeline configuration of the build. The
+// pipeline is looked up by repository ID, so it survives
+// repository renames.
+func (s *pipelineStore) Find(ctx context.Context, r *core.ConfigArgs) (*core.Config, error) {
+	for _, ref := range []string{r.Build.Ref, "default"} {
+		pipe, err := s.findRepo(ctx, r.Repo, ref, "")
+		if err != nil {
+			return nil, err
+		}
+		if pipe != nil {
+			return &core.Config{
+				Kind: "pipeline",
+				Data: pipe.Content,
+			}, nil
+		}
 	}
 	return nil, errors.New(r.Repo.Slug + r.Build.Ref + "pipeline not found")
 }
 
-const queryBySlugRef = `
-SELECT * FROM tpipe_pipelines 
-WHERE pipeline_slug=:pipeline_slug AND pipeline_ref=:pipeline_ref
+// helper function returns the pipeline of the repository for
+// the git reference and config path. The slug of a renamed
+// repository is updated when the pipeline is next saved.
+func (s *pipelineStore) findRepo(ctx context.Context, repo *core.Repository, ref, configPath string) (*model.Pipeline, error) {
+	out := &model.Pipeline{RepoID: repo.ID, Ref: ref, ConfigPath: configPath}
+	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
+		params, err := toParams(s.enc, out)
+		if err != nil {
+			return err
+		}
+		query, args, err := binder.BindNamed(queryByRepoRef, params)
+		if err != nil {
+			return err
+		}
+		row := queryer.QueryRow(query, args...)
+		return scanRow(s.enc, row, out)
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.Slug = repo.Slug
+	return out, nil
+}
+
+const queryByRepoRef = `
+SELECT
+ pipeline_uuid
+,pipeline_name
+,pipeline_repo
+,pipeline_slug
+,pipeline_ref
+,pipeline_config_path
+,pipeline_content
+,pipeline_created
+,pipeline_updated
+,pipeline_sync
+,pipeline_params
+,pipeline_template
+,pipeline_approval
+,pipeline_repo_id
+,pipeline_repo_uid
+FROM tpipe_pipelines
+WHERE pipeline_repo_id=:pipeline_repo_id AND pipeline_ref=:pipeline_ref AND pipeline_config_path=:pipeline_config_path
 `
 
 const queryBySlug = `
-SELECT * FROM tpipe_pipelines 
+SELECT
+ pipeline_uuid
+,pipeline_name
+,pipeline_repo
+,pipeline_slug
+,pipeline_ref
+,pipeline_config_path
+,pipeline_content
+,pipeline_created
+,pipeline_updated
+,pipeline_sync
+,pipeline_params
+,pipeline_template
+,pipeline_approval
+,pipeline_repo_id
+,pipeline_repo_uid
+FROM tpipe_pipelines
 WHERE pipeline_slug=:pipeline_slug
 `
 
+const queryByTemplate = `
+SELECT
+ pipeline_uuid
+,pipeline_name
+,pipeline_repo
+,pipeline_slug
+,pipeline_ref
+,pipeline_config_path
+,pipeline_content
+,pipeline_created
+,pipeline_updated
+,pipeline_sync
+,pipeline_params
+,pipeline_template
+,pipeline_approval
+,pipeline_repo_id
+,pipeline_repo_uid
+FROM tpipe_pipelines
+WHERE pipeline_template=:pipeline_template
+`
+
 const stmtInsert = `
 INSERT INTO tpipe_pipelines (
  pipeline_uuid
@@ -143,28 +383,113 @@ INSERT INTO tpipe_pipelines (
 ,pipeline_repo
 ,pipeline_slug
 ,pipeline_ref
+,pipeline_config_path
 ,pipeline_content
 ,pipeline_created
 ,pipeline_updated
 ,pipeline_sync
+,pipeline_params
+,pipeline_template
+,pipeline_approval
+,pipeline_repo_id
+,pipeline_repo_uid
 ) VALUES (
  :pipeline_uuid
 ,:pipeline_name
 ,:pipeline_repo
 ,:pipeline_slug
 ,:pipeline_ref
+,:pipeline_config_path
 ,:pipeline_content
 ,:pipeline_created
 ,:pipeline_updated
 ,:pipeline_sync
+,:pipeline_params
+,:pipeline_template
+,:pipeline_approval
+,:pipeline_repo_id
+,:pipeline_repo_uid
 )
 `
 
+const stmtUpsert = stmtInsert + `
+ON CONFLICT (pipeline_repo_id, pipeline_ref, pipeline_config_path) DO UPDATE SET
+ pipeline_content=excluded.pipeline_content
+,pipeline_updated=excluded.pipeline_updated
+,pipeline_sync=excluded.pipeline_sync
+,pipeline_template=CASE WHEN excluded.pipeline_template = '' THEN tpipe_pipelines.pipeline_template ELSE excluded.pipeline_template END
+,pipeline_slug=excluded.pipeline_slug
+,pipeline_repo_uid=excluded.pipeline_repo_uid
+`
+
+const stmtUpsertMysql = stmtInsert + `
+ON DUPLICATE KEY UPDATE
+ pipeline_content=VALUES(pipeline_content)
+,pipeline_updated=VALUES(pipeline_updated)
+,pipeline_sync=VALUES(pipeline_sync)
+,pipeline_template=IF(VALUES(pipeline_template) = '', pipeline_template, VALUES(pipeline_template))
+,pipeline_slug=VALUES(pipeline_slug)
+,pipeline_repo_uid=VALUES(pipeline_repo_uid)
+`
+
 const stmtUpdate = `
 UPDATE tpipe_pipelines SET
 pipeline_name=:pipeline_name
 ,pipeline_content=:pipeline_content
 ,pipeline_updated=:pipeline_updated
 ,pipeline_sync=:pipeline_sync
-WHERE pipeline_slug=:pipeline_slug AND pipeline_ref=:pipeline_ref
+,pipeline_params=:pipeline_params
+,pipeline_template=:pipeline_template
+,pipeline_approval=:pipeline_approval
+WHERE pipeline_uuid=:pipeline_uuid
+`
+
+const stmtDelete = `
+DELETE FROM tpipe_pipelines
+WHERE pipeline_uuid=:pipeline_uuid
+`
+
+const queryRevision = `
+SELECT
+ revision_id
+,revision_repo_id
+,revision_slug
+,revision_ref
+,revision_content
+,revision_created
+FROM tpipe_revisions
+WHERE revision_repo_id=:revision_repo_id
+AND revision_ref=:revision_ref
+AND revision_created<=:revision_created
+ORDER BY revision_created DESC, revision_id DESC
+LIMIT 1
+`
+
+const queryRevisionID = `
+SELECT
+ revision_id
+,revision_repo_id
+,revision_slug
+,revision_ref
+,revision_content
+,revision_created
+FROM tpipe_revisions
+WHERE revision_id=:revision_id
+AND revision_repo_id=:revision_repo_id
+`
+
+const stmtInsertRevision = `
+INSERT INTO tpipe_revisions (
+ revision_repo_id
+,revision_slug
+,revision_ref
+,revision_content
+,revision_created
+) VALUES (
+ :revision_repo_id
+,:revision_slug
+,:revision_ref
+,:revision_content
+,:revision_created
+)
 `
@@ -0,0 +1,92 @@
+package pipelines
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oars-sigs/drone/model"
+	extdb "github.com/oars-sigs/drone/store/shared/db"
+
+	"github.com/drone/drone/core"
+	"github.com/drone/drone/store/shared/encrypt"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSavePipeline_Renamed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipelines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := extdb.Connect("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	enc, err := encrypt.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(conn, enc)
+	ctx := context.Background()
+
+	prev := &core.Repository{ID: 1, UID: "1", Slug: "octocat/hello-world"}
+	err = store.SavePipeline(ctx, &model.Pipeline{
+		UUID:    "3e5a1b9c",
+		Slug:    prev.Slug,
+		RepoID:  prev.ID,
+		RepoUID: prev.UID,
+		Ref:     "default",
+		Content: "kind: pipeline\nname: prev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the repository is renamed, and a new repository takes
+	// its previous name and saves a pipeline first.
+	renamed := &core.Repository{ID: 1, UID: "1", Slug: "octocat/hello-world-old"}
+	repo := &core.Repository{ID: 2, UID: "2", Slug: "octocat/hello-world"}
+	err = store.SavePipeline(ctx, &model.Pipeline{
+		UUID:    "7d1f0c2a",
+		Slug:    repo.Slug,
+		RepoID:  repo.ID,
+		RepoUID: repo.UID,
+		Ref:     "default",
+		Content: "kind: pipeline\nname: next",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipe, isExist, err := store.GetPipeline(ctx, renamed, "default", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isExist || pipe.Content != "kind: pipeline\nname: prev" || pipe.Slug != renamed.Slug {
+		t.Errorf("Want the pipeline of the renamed repository, got %+v", pipe)
+	}
+	pipe, isExist, err = store.GetPipeline(ctx, repo, "default", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isExist || pipe.UUID != "7d1f0c2a" || pipe.Content != "kind: pipeline\nname: next" {
+		t.Errorf("Want the pipeline of the new repository, got %+v", pipe)
+	}
+
+	rev, err := store.FindRevision(ctx, renamed.ID, "default", time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev == nil || rev.Content != "kind: pipeline\nname: prev" {
+		t.Fatalf("Want the revisions of the renamed repository, got %+v", rev)
+	}
+	if rev, _ := store.GetRevision(ctx, repo.ID, rev.ID); rev != nil {
+		t.Errorf("Want revisions of other repositories not found, got %+v", rev)
+	}
+}
@@ -5,26 +5,43 @@ import (
 	"encoding/json"
 
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
 
 	"github.com/drone/drone/store/shared/db"
 	"github.com/jmoiron/sqlx/types"
 )
 
+// the config path of pipelines saved without one.
+const defaultConfigPath = ".drone.yml"
+
 // helper function converts the Plugin structure to a set
 // of named query parameters.
-func toParams(p *model.Pipeline) map[string]interface{} {
-
-	return map[string]interface{}{
-		"pipeline_uuid":    p.UUID,
-		"pipeline_name":    p.Name,
-		"pipeline_repo":    p.Repo,
-		"pipeline_slug":    p.Slug,
-		"pipeline_ref":     p.Ref,
-		"pipeline_sync":    p.Sync,
-		"pipeline_content": p.Content,
-		"pipeline_created": p.Created,
-		"pipeline_updated": p.Updated,
+func toParams(enc encrypt.Encrypter, p *model.Pipeline) (map[string]interface{}, error) {
+	configPath := p.ConfigPath
+	if configPath == "" {
+		configPath = defaultConfigPath
+	}
+	content, err := encrypt.EncryptText(enc, p.Content)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{
+		"pipeline_uuid":        p.UUID,
+		"pipeline_name":        p.Name,
+		"pipeline_repo":        p.Repo,
+		"pipeline_slug":        p.Slug,
+		"pipeline_repo_id":     p.RepoID,
+		"pipeline_repo_uid":    p.RepoUID,
+		"pipeline_ref":         p.Ref,
+		"pipeline_config_path": configPath,
+		"pipeline_sync":        p.Sync,
+		"pipeline_content":     content,
+		"pipeline_params":      encode(p.Params),
+		"pipeline_template":    p.Template,
+		"pipeline_approval":    encode(p.Approval),
+		"pipeline_created":     p.Created,
+		"pipeline_updated":     p.Updated,
+	}, nil
 }
 
 func encode(v interface{}) types.JSONText {
@@ -34,30 +51,64 @@ func encode(v interface{}) types.JSONText {
 
 // helper function scans the sql.Row and copies the column
 // values to the destination object.
-func scanRow(scanner db.Scanner, dest *model.Pipeline) error {
+func scanRow(enc encrypt.Encrypter, scanner db.Scanner, dest *model.Pipeline) error {
+	params := new(sql.NullString)
+	template := new(sql.NullString)
+	approval := new(sql.NullString)
+	configPath := new(sql.NullString)
+	repoID := new(sql.NullInt64)
+	repoUID := new(sql.NullString)
 	err := scanner.Scan(
 		&dest.UUID,
 		&dest.Name,
 		&dest.Repo,
 		&dest.Slug,
 		&dest.Ref,
+		configPath,
 		&dest.Content,
-		&dest.Updated,
 		&dest.Created,
+		&dest.Updated,
 		&dest.Sync,
+		params,
+		template,
+		approval,
+		repoID,
+		repoUID,
 	)
+	if err != nil {
+		return err
+	}
+	dest.Content, err = encrypt.DecryptText(enc, dest.Content)
+	if err != nil {
+		return err
+	}
+	dest.ConfigPath = configPath.String
+	dest.RepoID = repoID.Int64
+	dest.RepoUID = repoUID.String
+	dest.Template = template.String
+	dest.Params = nil
+	if params.String != "" {
+		err = json.Unmarshal([]byte(params.String), &dest.Params)
+		if err != nil {
+			return err
+		}
+	}
+	dest.Approval = nil
+	if approval.String != "" {
+		err = json.Unmarshal([]byte(approval.String), &dest.Approval)
+	}
 	return err
 }
 
 // helper function scans the sql.Row and copies the column
 // values to the destination object.
-func scanRows(rows *sql.Rows) ([]*model.Pipeline, error) {
+func scanRows(enc encrypt.Encrypter, rows *sql.Rows) ([]*model.Pipeline, error) {
 	defer rows.Close()
 
 	pipelines := []*model.Pipeline{}
 	for rows.Next() {
 		pipeline := new(model.Pipeline)
-		err := scanRow(rows, pipeline)
+		err := scanRow(enc, rows, pipeline)
 		if err != nil {
 			return nil, err
 		}
@@ -65,3 +116,40 @@ func scanRows(rows *sql.Rows) ([]*model.Pipeline, error) {
 	}
 	return pipelines, nil
 }
+
+// helper function converts the Revision structure to a set
+// of named query parameters.
+func toRevisionParams(enc encrypt.Encrypter, r *model.Revision) (map[string]interface{}, error) {
+	content, err := encrypt.EncryptText(enc, r.Content)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"revision_id":      r.ID,
+		"revision_repo_id": r.RepoID,
+		"revision_slug":    r.Slug,
+		"revision_ref":     r.Ref,
+		"revision_content": content,
+		"revision_created": r.Created,
+	}, nil
+}
+
+// helper function scans the sql.Row and copies the column
+// values to the destination object.
+func scanRevision(enc encrypt.Encrypter, scanner db.Scanner, dest *model.Revision) error {
+	repoID := new(sql.NullInt64)
+	err := scanner.Scan(
+		&dest.ID,
+		repoID,
+		&dest.Slug,
+		&dest.Ref,
+		&dest.Content,
+		&dest.Created,
+	)
+	if err != nil {
+		return err
+	}
+	dest.RepoID = repoID.Int64
+	dest.Content, err = encrypt.DecryptText(enc, dest.Content)
+	return err
+}
@@ -8,31 +8,163 @@ package db
 
 import (
 	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/oars-sigs/drone/store/shared/migrate/mysql"
 	"github.com/oars-sigs/drone/store/shared/migrate/postgres"
 	"github.com/oars-sigs/drone/store/shared/migrate/sqlite"
 
 	dblib "github.com/drone/drone/store/shared/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
 )
 
+// maximum wait between connection attempts.
+const maxBackoff = 30 * time.Second
+
+// Config provides the database connection pool and startup
+// configuration.
+type Config struct {
+	// MaxOpenConns limits the number of open connections.
+	// Zero means unlimited.
+	MaxOpenConns int
+	// MaxIdleConns limits the number of idle connections.
+	// Zero uses the driver default, a negative value keeps
+	// no idle connections.
+	MaxIdleConns int
+	// ConnMaxLifetime limits the time a connection is reused.
+	// Zero means connections are reused forever.
+	ConnMaxLifetime time.Duration
+	// ConnectTimeout is the time spent retrying to reach the
+	// database on startup. Zero means a single attempt.
+	ConnectTimeout time.Duration
+
+	// SqliteJournalMode sets the sqlite journal mode,
+	// for example WAL.
+	SqliteJournalMode string
+	// SqliteBusyTimeout sets the time sqlite waits for a
+	// locked database.
+	SqliteBusyTimeout time.Duration
+}
+
 // Connect to a database and verify with a ping.
 func Connect(driver, datasource string) (*dblib.DB, error) {
+	return ConnectConfig(driver, datasource, Config{})
+}
+
+// ConnectConfig connects to a database with the given pool
+// configuration. The database is pinged with backoff until
+// it can be reached or the connect timeout expires.
+func ConnectConfig(driver, datasource string, config Config) (*dblib.DB, error) {
+	if driver == "sqlite3" {
+		datasource = sqliteDatasource(datasource, config)
+	}
 	db, err := sql.Open(driver, datasource)
 	if err != nil {
 		return nil, err
 	}
-	switch driver {
-	case "mysql":
-		db.SetMaxIdleConns(0)
+	err = pingDatabase(db, config.ConnectTimeout)
+	db.Close()
+	if err != nil {
+		return nil, err
 	}
-	err = setupDatabase(db, driver)
+	// the drone schema is migrated first, since the tpipe
+	// migrations backfill from the drone tables.
+	conn, err := dblib.Connect(driver, datasource)
 	if err != nil {
-		db.Close()
 		return nil, err
 	}
-	db.Close()
-	return dblib.Connect(driver, datasource)
+	pool := Pool(conn)
+	if err := setupDatabase(pool, driver); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	configurePool(pool, driver, config)
+	return conn, nil
+}
+
+// Pool returns the connection pool of the database.
+func Pool(conn *dblib.DB) *sql.DB {
+	var pool *sql.DB
+	// the drone database passes the underlying connection
+	// to the view function.
+	conn.View(func(queryer dblib.Queryer, _ dblib.Binder) error {
+		if db, ok := queryer.(*sqlx.DB); ok {
+			pool = db.DB
+		}
+		return nil
+	})
+	return pool
+}
+
+// helper function to ping the database with exponential
+// backoff until it can be reached or the timeout expires.
+func pingDatabase(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := time.Second
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return err
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		logrus.WithError(err).
+			WithField("backoff", backoff).
+			Warnln("database: cannot ping the database, retrying")
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// helper function to apply the pool configuration.
+func configurePool(db *sql.DB, driver string, config Config) {
+	if db == nil {
+		return
+	}
+	switch {
+	case config.MaxIdleConns > 0:
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	case config.MaxIdleConns < 0:
+		db.SetMaxIdleConns(0)
+	}
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+}
+
+// helper function adds the journal mode and busy timeout to
+// the sqlite datasource, unless already configured.
+func sqliteDatasource(datasource string, config Config) string {
+	var params []string
+	if config.SqliteJournalMode != "" &&
+		!strings.Contains(datasource, "_journal") {
+		params = append(params, "_journal_mode="+url.QueryEscape(config.SqliteJournalMode))
+	}
+	if config.SqliteBusyTimeout > 0 &&
+		!strings.Contains(datasource, "_timeout") {
+		params = append(params, "_busy_timeout="+strconv.FormatInt(int64(config.SqliteBusyTimeout/time.Millisecond), 10))
+	}
+	if len(params) == 0 {
+		return datasource
+	}
+	if strings.Contains(datasource, "?") {
+		return datasource + "&" + strings.Join(params, "&")
+	}
+	return datasource + "?" + strings.Join(params, "&")
 }
 
 // helper function to setup the databsae by performing automated
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -25,3 +27,54 @@ func TestConnectMysql(t *testing.T) {
 	}
 	db.Close()
 }
+
+func TestConnectConfig(t *testing.T) {
+	defer os.Remove("./pool.sqlite")
+	defer os.Remove("./pool.sqlite-shm")
+	defer os.Remove("./pool.sqlite-wal")
+
+	conn, err := ConnectConfig("sqlite3", "./pool.sqlite", Config{
+		MaxOpenConns:      4,
+		SqliteJournalMode: "WAL",
+		SqliteBusyTimeout: time.Second,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	pool := Pool(conn)
+	if pool == nil {
+		t.Errorf("Want connection pool")
+		return
+	}
+	if got, want := pool.Stats().MaxOpenConnections, 4; got != want {
+		t.Errorf("Want max open connections %d, got %d", want, got)
+	}
+	var mode string
+	pool.QueryRow("PRAGMA journal_mode").Scan(&mode)
+	if got, want := mode, "wal"; got != want {
+		t.Errorf("Want journal mode %s, got %s", want, got)
+	}
+}
+
+func TestSqliteDatasource(t *testing.T) {
+	config := Config{
+		SqliteJournalMode: "WAL",
+		SqliteBusyTimeout: 5 * time.Second,
+	}
+	tests := []struct {
+		datasource string
+		want       string
+	}{
+		{"core.sqlite", "core.sqlite?_journal_mode=WAL&_busy_timeout=5000"},
+		{"file:core.sqlite?cache=shared", "file:core.sqlite?cache=shared&_journal_mode=WAL&_busy_timeout=5000"},
+		{"core.sqlite?_journal=DELETE&_timeout=100", "core.sqlite?_journal=DELETE&_timeout=100"},
+	}
+	for _, test := range tests {
+		if got := sqliteDatasource(test.datasource, config); got != test.want {
+			t.Errorf("Want datasource %s, got %s", test.want, got)
+		}
+	}
+}
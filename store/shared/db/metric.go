@@ -0,0 +1,53 @@
+// +build !oss
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics registers the connection pool statistics of
+// the database with the default prometheus registry.
+func RegisterMetrics(db *sql.DB) {
+	stats := func(fn func(sql.DBStats) float64) func() float64 {
+		return func() float64 {
+			return fn(db.Stats())
+		}
+	}
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "drone_database_max_open_connections",
+			Help: "Maximum number of open connections to the database.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "drone_database_open_connections",
+			Help: "Number of established connections to the database.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "drone_database_in_use_connections",
+			Help: "Number of connections currently in use.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.InUse) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "drone_database_idle_connections",
+			Help: "Number of idle connections.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.Idle) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "drone_database_wait_count_total",
+			Help: "Total number of connections waited for.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "drone_database_wait_duration_seconds_total",
+			Help: "Total time blocked waiting for a new connection.",
+		}, stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "drone_database_max_idle_closed_total",
+			Help: "Total number of connections closed due to the idle limit.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "drone_database_max_lifetime_closed_total",
+			Help: "Total number of connections closed due to the lifetime limit.",
+		}, stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })),
+	)
+}
@@ -0,0 +1,129 @@
+// Package encrypt encrypts content stored in text columns,
+// such as the pipeline and template content, with the database
+// encrypter. Content written without an encryption key, or
+// before encryption was enabled, is stored and read as plain
+// text.
+package encrypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drone/drone/store/shared/db"
+	"github.com/drone/drone/store/shared/encrypt"
+)
+
+// prefix of encrypted content.
+const prefix = "aesgcm:"
+
+// ErrKeyRequired is returned when encrypted content is read
+// without an encryption key.
+var ErrKeyRequired = errors.New("encrypt: encrypted content requires the database secret")
+
+// Encrypter encrypts and decrypts content.
+type Encrypter = encrypt.Encrypter
+
+// EncryptText encrypts the plaintext. If the encrypter has no
+// key, the plaintext is returned.
+func EncryptText(enc Encrypter, plaintext string) (string, error) {
+	if plaintext == "" || !Enabled(enc) {
+		return plaintext, nil
+	}
+	ciphertext, err := enc.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return prefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptText decrypts the text. Text that is not encrypted is
+// returned as is.
+func DecryptText(enc Encrypter, text string) (string, error) {
+	if !IsEncrypted(text) {
+		return text, nil
+	}
+	if !Enabled(enc) {
+		return "", ErrKeyRequired
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, prefix))
+	if err != nil {
+		return "", err
+	}
+	return enc.Decrypt(ciphertext)
+}
+
+// IsEncrypted returns true if the text is encrypted.
+func IsEncrypted(text string) bool {
+	return strings.HasPrefix(text, prefix)
+}
+
+// Enabled returns true if the encrypter has a key. The
+// encrypter without a key returns the plaintext unchanged.
+func Enabled(enc Encrypter) bool {
+	if enc == nil {
+		return false
+	}
+	ciphertext, err := enc.Encrypt("")
+	return err == nil && len(ciphertext) != 0
+}
+
+// Rotate decrypts the column of each row in the table with
+// the previous encrypter and encrypts it with the next
+// encrypter. It returns the number of rows updated.
+func Rotate(conn *db.DB, table, key, column string, prev, next Encrypter) (int, error) {
+	type row struct {
+		key  string
+		text string
+	}
+	var rows []row
+	err := conn.View(func(queryer db.Queryer, binder db.Binder) error {
+		res, err := queryer.Query(fmt.Sprintf("SELECT %s, %s FROM %s", key, column, table))
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		for res.Next() {
+			var r row
+			if err := res.Scan(&r.key, &r.text); err != nil {
+				return err
+			}
+			rows = append(rows, r)
+		}
+		return res.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := fmt.Sprintf("UPDATE %s SET %s=:text WHERE %s=:key", table, column, key)
+	n := 0
+	for _, r := range rows {
+		plaintext, err := DecryptText(prev, r.text)
+		if err != nil {
+			return n, fmt.Errorf("encrypt: %s %s: %s", table, r.key, err)
+		}
+		text, err := EncryptText(next, plaintext)
+		if err != nil {
+			return n, err
+		}
+		if text == r.text {
+			continue
+		}
+		err = conn.Lock(func(execer db.Execer, binder db.Binder) error {
+			params := map[string]interface{}{"text": text, "key": r.key}
+			query, args, err := binder.BindNamed(stmt, params)
+			if err != nil {
+				return err
+			}
+			_, err = execer.Exec(query, args...)
+			return err
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,55 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/drone/drone/store/shared/encrypt"
+)
+
+func TestEncryptText(t *testing.T) {
+	enc, err := encrypt.New("fb4b4d6267c8a5ce8231f8b186dbca92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := EncryptText(enc, "kind: pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(text) {
+		t.Errorf("Want encrypted text, got %s", text)
+	}
+	plaintext, err := DecryptText(enc, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := plaintext, "kind: pipeline"; got != want {
+		t.Errorf("Want plaintext %q, got %q", want, got)
+	}
+
+	// content stored before encryption was enabled is read
+	// as plain text.
+	plaintext, err = DecryptText(enc, "kind: secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := plaintext, "kind: secret"; got != want {
+		t.Errorf("Want plaintext %q, got %q", want, got)
+	}
+}
+
+func TestEncryptTextNoKey(t *testing.T) {
+	none, _ := encrypt.New("")
+	text, err := EncryptText(none, "kind: pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := text, "kind: pipeline"; got != want {
+		t.Errorf("Want plain text %q, got %q", want, got)
+	}
+
+	enc, _ := encrypt.New("fb4b4d6267c8a5ce8231f8b186dbca92")
+	text, _ = EncryptText(enc, "kind: pipeline")
+	if _, err := DecryptText(none, text); err != ErrKeyRequired {
+		t.Errorf("Want key required error, got %v", err)
+	}
+}
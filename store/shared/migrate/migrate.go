@@ -0,0 +1,380 @@
+// Package migrate applies and reverts the tpipe schema
+// migrations. The dialect packages provide the migrations
+// and the sql used to track them.
+package migrate
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownMigration is returned when the database has
+	// applied migrations that are unknown to this version,
+	// for example after a downgrade.
+	ErrUnknownMigration = errors.New("migrate: database has migrations unknown to this version")
+
+	// ErrIrreversible is returned when a migration without a
+	// down statement is reverted.
+	ErrIrreversible = errors.New("migrate: migration is irreversible")
+
+	// ErrLegacy is returned when a migration recorded in the
+	// legacy migrations table is reverted before the migrations
+	// are imported by Up.
+	ErrLegacy = errors.New("migrate: migration is recorded in the legacy migrations table, run up first")
+)
+
+// names of the migration table and of the legacy migrations
+// table shared with drone.
+const (
+	table       = "tpipe_migrations"
+	legacyTable = "migrations"
+)
+
+// Migration is a schema migration.
+type Migration struct {
+	Name string
+	Stmt string
+	Down string
+}
+
+// Checksum returns the sha256 checksum of the migration
+// statement.
+func (m *Migration) Checksum() string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(m.Stmt)))
+}
+
+// Dialect provides the sql used to track the applied
+// migrations.
+type Dialect struct {
+	// Exists counts the tables with the name.
+	Exists string
+	// Create creates the migration table.
+	Create string
+	// Insert inserts the name, checksum and applied time.
+	Insert string
+	// Delete deletes the migration by name.
+	Delete string
+	// Select selects the name, checksum and applied time.
+	Select string
+
+	// LegacyCreate, LegacySelect and LegacyDelete manage the
+	// migrations table shared with drone, which only records
+	// the migration name.
+	LegacyCreate string
+	LegacySelect string
+	LegacyDelete string
+}
+
+// Status is the status of a migration.
+type Status struct {
+	Name     string `json:"name"`
+	Checksum string `json:"checksum,omitempty"`
+	Applied  int64  `json:"applied,omitempty"`
+	Pending  bool   `json:"pending,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+	Unknown  bool   `json:"unknown,omitempty"`
+
+	// legacy is set if the migration is only recorded in the
+	// legacy migrations table.
+	legacy bool
+}
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New returns a new Migrator.
+func New(db *sql.DB, dialect Dialect, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}
+}
+
+// Up applies the pending migrations and returns the number of
+// migrations applied. The migration table is created, and the
+// migrations recorded in the legacy migrations table are
+// imported, before the migrations are applied.
+func (m *Migrator) Up() (int, error) {
+	if _, err := m.db.Exec(m.dialect.Create); err != nil {
+		return 0, err
+	}
+	if err := m.importLegacy(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Name]; ok {
+			continue
+		}
+		err := m.exec(migration.Stmt, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.dialect.Insert, migration.Name, migration.Checksum(), time.Now().Unix())
+			return err
+		})
+		if err != nil {
+			return n, fmt.Errorf("migrate: %s: %s", migration.Name, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Down reverts the last n applied migrations, most recent
+// first, and returns the number of migrations reverted. Each
+// migration is reverted in a transaction, but MySQL commits
+// DDL statements implicitly, so a failed revert may leave the
+// schema changed while the migration is still recorded.
+func (m *Migrator) Down(n int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	for name := range applied {
+		if m.find(name) == nil {
+			return 0, ErrUnknownMigration
+		}
+	}
+	reverted := 0
+	for i := len(m.migrations) - 1; i >= 0 && reverted < n; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Name]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return reverted, fmt.Errorf("%s: %s", ErrIrreversible, migration.Name)
+		}
+		if applied[migration.Name].legacy {
+			return reverted, fmt.Errorf("%s: %s", ErrLegacy, migration.Name)
+		}
+		err := m.exec(migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.dialect.Delete, migration.Name)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migrate: %s: %s", migration.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Status returns the status of the known migrations, in
+// order, followed by the applied migrations unknown to this
+// version. Status does not write to the database.
+func (m *Migrator) Status() ([]*Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var out []*Status
+	for _, migration := range m.migrations {
+		status, ok := applied[migration.Name]
+		if !ok {
+			out = append(out, &Status{Name: migration.Name, Pending: true})
+			continue
+		}
+		status.Modified = status.Checksum != migration.Checksum()
+		out = append(out, status)
+	}
+	for name, status := range applied {
+		if m.find(name) == nil {
+			status.Unknown = true
+			out = append(out, status)
+		}
+	}
+	return out, nil
+}
+
+// Verify returns an error if an applied migration was modified
+// or is unknown to this version.
+func (m *Migrator) Verify() error {
+	list, err := m.Status()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, status := range list {
+		switch {
+		case status.Modified:
+			problems = append(problems, status.Name+" was modified after it was applied")
+		case status.Unknown:
+			problems = append(problems, status.Name+" is unknown to this version")
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("migrate: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// helper function returns the known migration with the name.
+func (m *Migrator) find(name string) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Name == name {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// helper function executes the statement and records the
+// result in a single transaction.
+func (m *Migrator) exec(stmt string, record func(*sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if !isComment(stmt) {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// helper function returns true if the statement only has
+// comments. This is used by migrations that are safe to
+// revert but have nothing to undo.
+func isComment(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// helper function returns the applied migrations by name,
+// including the known migrations that are only recorded in
+// the legacy migrations table. It does not write to the
+// database, and returns no migrations if the migration table
+// does not exist.
+func (m *Migrator) applied() (map[string]*Status, error) {
+	applied := map[string]*Status{}
+	ok, err := m.exists(table)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		rows, err := m.db.Query(m.dialect.Select)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			status := new(Status)
+			if err := rows.Scan(&status.Name, &status.Checksum, &status.Applied); err != nil {
+				return nil, err
+			}
+			applied[status.Name] = status
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	names, err := m.legacy()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		migration := m.find(name)
+		if migration == nil {
+			// the migrations table is shared with drone.
+			continue
+		}
+		if _, ok := applied[name]; ok {
+			continue
+		}
+		applied[name] = &Status{
+			Name:     name,
+			Checksum: migration.Checksum(),
+			legacy:   true,
+		}
+	}
+	return applied, nil
+}
+
+// helper function returns true if the table exists.
+func (m *Migrator) exists(name string) (bool, error) {
+	var count int
+	err := m.db.QueryRow(m.dialect.Exists, name).Scan(&count)
+	return count != 0, err
+}
+
+// helper function returns the migration names recorded in the
+// legacy migrations table, if it exists.
+func (m *Migrator) legacy() ([]string, error) {
+	ok, err := m.exists(legacyTable)
+	if err != nil || !ok {
+		return nil, err
+	}
+	rows, err := m.db.Query(m.dialect.LegacySelect)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// helper function moves the migrations recorded by earlier
+// versions in the legacy migrations table to the migration
+// table. The applied time of these migrations is unknown.
+func (m *Migrator) importLegacy() error {
+	if _, err := m.db.Exec(m.dialect.LegacyCreate); err != nil {
+		return err
+	}
+	names, err := m.legacy()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		migration := m.find(name)
+		if migration == nil {
+			// the migrations table is shared with drone.
+			continue
+		}
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.dialect.Insert, migration.Name, migration.Checksum(), 0); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(m.dialect.LegacyDelete, migration.Name); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
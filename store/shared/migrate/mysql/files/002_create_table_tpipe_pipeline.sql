@@ -14,4 +14,38 @@ CREATE TABLE IF NOT EXISTS tpipe_pipelines (
 
 -- name: alter-table-pipelines-add-column-sync
 
-ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_sync INT(2) DEFAULT 0;
\ No newline at end of file
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_sync INT(2) DEFAULT 0;
+
+-- name: alter-table-pipelines-add-column-params
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_params TEXT;
+
+
+-- name: alter-table-pipelines-add-column-template
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_template VARCHAR(40);
+
+
+-- name: alter-table-pipelines-add-column-approval
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_approval TEXT;
+
+-- name: drop-table-tpipe-pipeline
+
+DROP TABLE IF EXISTS tpipe_pipelines;
+
+-- name: alter-table-pipelines-drop-column-sync
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_sync;
+
+-- name: alter-table-pipelines-drop-column-params
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_params;
+
+-- name: alter-table-pipelines-drop-column-template
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_template;
+
+-- name: alter-table-pipelines-drop-column-approval
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_approval;
@@ -0,0 +1,14 @@
+-- name: create-table-tpipe-approval
+
+CREATE TABLE IF NOT EXISTS tpipe_approvals (
+	approval_build_id INTEGER,
+	approval_slug VARCHAR(250),
+	approval_number INTEGER,
+	approval_user VARCHAR(255),
+	approval_created INTEGER,
+	UNIQUE ( approval_build_id, approval_user )
+);
+
+-- name: drop-table-tpipe-approval
+
+DROP TABLE IF EXISTS tpipe_approvals;
@@ -0,0 +1,31 @@
+-- name: create-table-tpipe-revision
+
+CREATE TABLE IF NOT EXISTS tpipe_revisions (
+	revision_id INTEGER PRIMARY KEY AUTO_INCREMENT,
+	revision_slug VARCHAR(250),
+	revision_ref VARCHAR(255),
+	revision_content MEDIUMTEXT,
+	revision_created INTEGER
+);
+
+-- name: create-index-tpipe-revision-slug-ref
+
+CREATE INDEX ix_tpipe_revision_slug_ref ON tpipe_revisions (revision_slug, revision_ref);
+
+-- name: backfill-tpipe-revisions
+
+INSERT INTO tpipe_revisions (revision_slug, revision_ref, revision_content, revision_created)
+SELECT pipeline_slug, pipeline_ref, pipeline_content, pipeline_created
+FROM tpipe_pipelines;
+
+-- name: drop-table-tpipe-revision
+
+DROP TABLE IF EXISTS tpipe_revisions;
+
+-- name: drop-index-tpipe-revision-slug-ref
+
+DROP INDEX ix_tpipe_revision_slug_ref ON tpipe_revisions;
+
+-- name: delete-tpipe-revisions
+
+DELETE FROM tpipe_revisions;
@@ -0,0 +1,7 @@
+-- name: alter-table-templates-widen-column-content
+
+ALTER TABLE tpipe_templates MODIFY template_content MEDIUMTEXT;
+
+-- name: revert-alter-table-templates-widen-column-content
+
+ALTER TABLE tpipe_templates MODIFY template_content TEXT;
@@ -0,0 +1,33 @@
+-- name: create-table-tpipe-audit
+
+CREATE TABLE IF NOT EXISTS tpipe_audits (
+	audit_id INTEGER PRIMARY KEY AUTO_INCREMENT,
+	audit_actor VARCHAR(250),
+	audit_action VARCHAR(250),
+	audit_target VARCHAR(500),
+	audit_before VARCHAR(100),
+	audit_after VARCHAR(100),
+	audit_source_ip VARCHAR(100),
+	audit_status INTEGER,
+	audit_created INTEGER
+);
+
+-- name: create-index-tpipe-audit-created
+
+CREATE INDEX ix_tpipe_audit_created ON tpipe_audits (audit_created);
+
+-- name: create-index-tpipe-audit-actor
+
+CREATE INDEX ix_tpipe_audit_actor ON tpipe_audits (audit_actor);
+
+-- name: drop-table-tpipe-audit
+
+DROP TABLE IF EXISTS tpipe_audits;
+
+-- name: drop-index-tpipe-audit-created
+
+DROP INDEX ix_tpipe_audit_created ON tpipe_audits;
+
+-- name: drop-index-tpipe-audit-actor
+
+DROP INDEX ix_tpipe_audit_actor ON tpipe_audits;
@@ -0,0 +1,7 @@
+-- name: create-index-tpipe-override-build-id
+
+CREATE INDEX ix_tpipe_override_build_id ON tpipe_overrides (override_build_id);
+
+-- name: drop-index-tpipe-override-build-id
+
+DROP INDEX ix_tpipe_override_build_id ON tpipe_overrides;
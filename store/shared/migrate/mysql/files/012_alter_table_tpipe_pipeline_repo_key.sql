@@ -0,0 +1,70 @@
+-- name: backfill-tpipe-pipeline-repo-link
+
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = (SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '')
+WHERE pipeline_repo_id IS NULL OR pipeline_repo_id = 0;
+
+-- name: unlink-duplicate-tpipe-pipelines
+
+UPDATE tpipe_pipelines a
+JOIN tpipe_pipelines b
+ON a.pipeline_repo_id = b.pipeline_repo_id
+AND a.pipeline_ref = b.pipeline_ref
+AND a.pipeline_config_path = b.pipeline_config_path
+AND (COALESCE(a.pipeline_updated, 0) < COALESCE(b.pipeline_updated, 0) OR (COALESCE(a.pipeline_updated, 0) = COALESCE(b.pipeline_updated, 0) AND a.pipeline_uuid < b.pipeline_uuid))
+SET a.pipeline_repo_id = NULL;
+
+-- name: remove-unique-index-tpipe-pipeline-slug-ref-path
+
+DROP INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines;
+
+-- name: create-unique-index-tpipe-pipeline-repo-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_repo_ref_path ON tpipe_pipelines (pipeline_repo_id, pipeline_ref(255), pipeline_config_path(250));
+
+-- name: alter-table-revisions-add-column-repo-id
+
+ALTER TABLE tpipe_revisions ADD COLUMN revision_repo_id BIGINT DEFAULT 0;
+
+-- name: backfill-tpipe-revision-repo-id
+
+UPDATE tpipe_revisions SET revision_repo_id = COALESCE(
+	(SELECT MAX(pipeline_repo_id) FROM tpipe_pipelines WHERE tpipe_pipelines.pipeline_slug = tpipe_revisions.revision_slug),
+	(SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_revisions.revision_slug),
+	0
+);
+
+-- name: create-index-tpipe-revision-repo-ref
+
+CREATE INDEX ix_tpipe_revision_repo_ref ON tpipe_revisions (revision_repo_id, revision_ref);
+
+-- name: revert-backfill-tpipe-pipeline-repo-link
+
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0 WHERE pipeline_repo_id IS NULL;
+
+-- name: revert-unlink-duplicate-tpipe-pipelines
+
+-- the older duplicates stay unlinked. They are kept, and are
+-- reset to pipelines without a repository when the links are
+-- reverted.
+
+-- name: revert-remove-unique-index-tpipe-pipeline-slug-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug(250), pipeline_ref(255), pipeline_config_path(250));
+
+-- name: drop-unique-index-tpipe-pipeline-repo-ref-path
+
+DROP INDEX ux_tpipe_pipeline_repo_ref_path ON tpipe_pipelines;
+
+-- name: alter-table-revisions-drop-column-repo-id
+
+ALTER TABLE tpipe_revisions DROP COLUMN revision_repo_id;
+
+-- name: revert-backfill-tpipe-revision-repo-id
+
+UPDATE tpipe_revisions SET revision_repo_id = 0;
+
+-- name: drop-index-tpipe-revision-repo-ref
+
+DROP INDEX ix_tpipe_revision_repo_ref ON tpipe_revisions;
@@ -10,4 +10,8 @@ CREATE TABLE IF NOT EXISTS tpipe_templates (
 	template_created INT,
 	UNIQUE ( template_uuid ),
 	UNIQUE ( template_name )
-);
\ No newline at end of file
+);
+
+-- name: drop-table-tpipe-template
+
+DROP TABLE IF EXISTS tpipe_templates;
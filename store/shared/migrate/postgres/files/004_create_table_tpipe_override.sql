@@ -0,0 +1,16 @@
+-- name: create-table-tpipe-override
+
+CREATE TABLE IF NOT EXISTS tpipe_overrides (
+	override_uuid VARCHAR(40),
+	override_slug VARCHAR(250),
+	override_build_id INTEGER,
+	override_number INTEGER,
+	override_content TEXT,
+	override_creator VARCHAR(255),
+	override_created INTEGER,
+	UNIQUE ( override_uuid )
+);
+
+-- name: drop-table-tpipe-override
+
+DROP TABLE IF EXISTS tpipe_overrides;
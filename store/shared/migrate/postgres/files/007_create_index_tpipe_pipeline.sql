@@ -0,0 +1,35 @@
+-- name: alter-table-pipelines-add-column-config-path
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_config_path VARCHAR(250) DEFAULT '.drone.yml';
+
+-- name: dedupe-tpipe-pipelines
+
+-- the most recently updated pipeline is kept. The duplicates
+-- are deleted, so the migration cannot be reverted.
+
+DELETE FROM tpipe_pipelines a
+USING tpipe_pipelines b
+WHERE a.pipeline_slug = b.pipeline_slug
+AND a.pipeline_ref = b.pipeline_ref
+AND a.pipeline_config_path = b.pipeline_config_path
+AND (COALESCE(a.pipeline_updated, 0) < COALESCE(b.pipeline_updated, 0) OR (COALESCE(a.pipeline_updated, 0) = COALESCE(b.pipeline_updated, 0) AND a.pipeline_uuid < b.pipeline_uuid));
+
+-- name: create-unique-index-tpipe-pipeline-slug-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+
+-- name: create-index-tpipe-pipeline-slug
+
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+
+-- name: alter-table-pipelines-drop-column-config-path
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_config_path;
+
+-- name: drop-unique-index-tpipe-pipeline-slug-ref-path
+
+DROP INDEX ux_tpipe_pipeline_slug_ref_path;
+
+-- name: drop-index-tpipe-pipeline-slug
+
+DROP INDEX ix_tpipe_pipeline_slug;
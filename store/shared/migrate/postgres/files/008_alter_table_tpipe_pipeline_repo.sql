@@ -0,0 +1,33 @@
+-- name: alter-table-pipelines-add-column-repo-id
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_id BIGINT DEFAULT 0;
+
+-- name: alter-table-pipelines-add-column-repo-uid
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_uid VARCHAR(250) DEFAULT '';
+
+-- name: create-index-tpipe-pipeline-repo-id
+
+CREATE INDEX ix_tpipe_pipeline_repo_id ON tpipe_pipelines (pipeline_repo_id);
+
+-- name: backfill-tpipe-pipeline-repo-id
+
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = COALESCE((SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), 0)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '');
+
+-- name: alter-table-pipelines-drop-column-repo-id
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_repo_id;
+
+-- name: alter-table-pipelines-drop-column-repo-uid
+
+ALTER TABLE tpipe_pipelines DROP COLUMN pipeline_repo_uid;
+
+-- name: drop-index-tpipe-pipeline-repo-id
+
+DROP INDEX ix_tpipe_pipeline_repo_id;
+
+-- name: revert-backfill-tpipe-pipeline-repo-id
+
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0, pipeline_repo_uid = '';
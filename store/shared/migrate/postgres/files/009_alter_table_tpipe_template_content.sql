@@ -0,0 +1,7 @@
+-- name: alter-table-templates-widen-column-content
+
+ALTER TABLE tpipe_templates ALTER COLUMN template_content TYPE TEXT;
+
+-- name: revert-alter-table-templates-widen-column-content
+
+ALTER TABLE tpipe_templates ALTER COLUMN template_content TYPE VARCHAR(40960);
@@ -0,0 +1,71 @@
+-- name: backfill-tpipe-pipeline-repo-link
+
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = (SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '')
+WHERE pipeline_repo_id IS NULL OR pipeline_repo_id = 0;
+
+-- name: unlink-duplicate-tpipe-pipelines
+
+UPDATE tpipe_pipelines SET pipeline_repo_id = NULL
+WHERE EXISTS (
+	SELECT 1 FROM tpipe_pipelines b
+	WHERE b.pipeline_repo_id = tpipe_pipelines.pipeline_repo_id
+	AND b.pipeline_ref = tpipe_pipelines.pipeline_ref
+	AND b.pipeline_config_path = tpipe_pipelines.pipeline_config_path
+	AND (COALESCE(b.pipeline_updated, 0) > COALESCE(tpipe_pipelines.pipeline_updated, 0) OR (COALESCE(b.pipeline_updated, 0) = COALESCE(tpipe_pipelines.pipeline_updated, 0) AND b.pipeline_uuid > tpipe_pipelines.pipeline_uuid))
+);
+
+-- name: remove-unique-index-tpipe-pipeline-slug-ref-path
+
+DROP INDEX ux_tpipe_pipeline_slug_ref_path;
+
+-- name: create-unique-index-tpipe-pipeline-repo-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_repo_ref_path ON tpipe_pipelines (pipeline_repo_id, pipeline_ref, pipeline_config_path);
+
+-- name: alter-table-revisions-add-column-repo-id
+
+ALTER TABLE tpipe_revisions ADD COLUMN revision_repo_id BIGINT DEFAULT 0;
+
+-- name: backfill-tpipe-revision-repo-id
+
+UPDATE tpipe_revisions SET revision_repo_id = COALESCE(
+	(SELECT MAX(pipeline_repo_id) FROM tpipe_pipelines WHERE tpipe_pipelines.pipeline_slug = tpipe_revisions.revision_slug),
+	(SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_revisions.revision_slug),
+	0
+);
+
+-- name: create-index-tpipe-revision-repo-ref
+
+CREATE INDEX ix_tpipe_revision_repo_ref ON tpipe_revisions (revision_repo_id, revision_ref);
+
+-- name: revert-backfill-tpipe-pipeline-repo-link
+
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0 WHERE pipeline_repo_id IS NULL;
+
+-- name: revert-unlink-duplicate-tpipe-pipelines
+
+-- the older duplicates stay unlinked. They are kept, and are
+-- reset to pipelines without a repository when the links are
+-- reverted.
+
+-- name: revert-remove-unique-index-tpipe-pipeline-slug-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+
+-- name: drop-unique-index-tpipe-pipeline-repo-ref-path
+
+DROP INDEX ux_tpipe_pipeline_repo_ref_path;
+
+-- name: alter-table-revisions-drop-column-repo-id
+
+ALTER TABLE tpipe_revisions DROP COLUMN revision_repo_id;
+
+-- name: revert-backfill-tpipe-revision-repo-id
+
+UPDATE tpipe_revisions SET revision_repo_id = 0;
+
+-- name: drop-index-tpipe-revision-repo-ref
+
+DROP INDEX ix_tpipe_revision_repo_ref;
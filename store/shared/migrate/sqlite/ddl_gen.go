@@ -2,95 +2,233 @@ package sqlite
 
 import (
 	"database/sql"
+
+	"github.com/oars-sigs/drone/store/shared/migrate"
 )
 
-var migrations = []struct {
-	name string
-	stmt string
-}{
+var migrations = []migrate.Migration{
+	{
+		Name: "create-table-tpipe-template",
+		Stmt: createTableTpipeTemplate,
+		Down: dropTableTpipeTemplate,
+	},
+	{
+		Name: "create-table-tpipe-pipeline",
+		Stmt: createTableTpipePipeline,
+		Down: dropTableTpipePipeline,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-params",
+		Stmt: alterTablePipelinesAddColumnParams,
+		Down: alterTablePipelinesDropColumnParams,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-template",
+		Stmt: alterTablePipelinesAddColumnTemplate,
+		Down: alterTablePipelinesDropColumnTemplate,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-approval",
+		Stmt: alterTablePipelinesAddColumnApproval,
+		Down: alterTablePipelinesDropColumnApproval,
+	},
+	{
+		Name: "create-table-tpipe-credential",
+		Stmt: createTableTpipeCredential,
+		Down: dropTableTpipeCredential,
+	},
+	{
+		Name: "create-table-tpipe-override",
+		Stmt: createTableTpipeOverride,
+		Down: dropTableTpipeOverride,
+	},
+	{
+		Name: "create-table-tpipe-approval",
+		Stmt: createTableTpipeApproval,
+		Down: dropTableTpipeApproval,
+	},
+	{
+		Name: "create-table-tpipe-revision",
+		Stmt: createTableTpipeRevision,
+		Down: dropTableTpipeRevision,
+	},
+	{
+		Name: "create-index-tpipe-revision-slug-ref",
+		Stmt: createIndexTpipeRevisionSlugRef,
+		Down: dropIndexTpipeRevisionSlugRef,
+	},
+	{
+		Name: "backfill-tpipe-revisions",
+		Stmt: backfillTpipeRevisions,
+		Down: deleteTpipeRevisions,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-config-path",
+		Stmt: alterTablePipelinesAddColumnConfigPath,
+		Down: alterTablePipelinesDropColumnConfigPath,
+	},
+	{
+		Name: "dedupe-tpipe-pipelines",
+		Stmt: dedupeTpipePipelines,
+	},
+	{
+		Name: "create-unique-index-tpipe-pipeline-slug-ref-path",
+		Stmt: createUniqueIndexTpipePipelineSlugRefPath,
+		Down: dropUniqueIndexTpipePipelineSlugRefPath,
+	},
+	{
+		Name: "create-index-tpipe-pipeline-slug",
+		Stmt: createIndexTpipePipelineSlug,
+		Down: dropIndexTpipePipelineSlug,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-repo-id",
+		Stmt: alterTablePipelinesAddColumnRepoId,
+		Down: alterTablePipelinesDropColumnRepoId,
+	},
+	{
+		Name: "alter-table-pipelines-add-column-repo-uid",
+		Stmt: alterTablePipelinesAddColumnRepoUid,
+		Down: alterTablePipelinesDropColumnRepoUid,
+	},
+	{
+		Name: "create-index-tpipe-pipeline-repo-id",
+		Stmt: createIndexTpipePipelineRepoId,
+		Down: dropIndexTpipePipelineRepoId,
+	},
 	{
-		name: "create-table-tpipe-template",
-		stmt: createTableTpipeTemplate,
+		Name: "backfill-tpipe-pipeline-repo-id",
+		Stmt: backfillTpipePipelineRepoId,
+		Down: revertBackfillTpipePipelineRepoId,
 	},
 	{
-		name: "create-table-tpipe-pipeline",
-		stmt: createTableTpipePipeline,
+		Name: "create-table-tpipe-audit",
+		Stmt: createTableTpipeAudit,
+		Down: dropTableTpipeAudit,
+	},
+	{
+		Name: "create-index-tpipe-audit-created",
+		Stmt: createIndexTpipeAuditCreated,
+		Down: dropIndexTpipeAuditCreated,
+	},
+	{
+		Name: "create-index-tpipe-audit-actor",
+		Stmt: createIndexTpipeAuditActor,
+		Down: dropIndexTpipeAuditActor,
+	},
+	{
+		Name: "create-index-tpipe-override-build-id",
+		Stmt: createIndexTpipeOverrideBuildId,
+		Down: dropIndexTpipeOverrideBuildId,
+	},
+	{
+		Name: "backfill-tpipe-pipeline-repo-link",
+		Stmt: backfillTpipePipelineRepoLink,
+		Down: revertBackfillTpipePipelineRepoLink,
+	},
+	{
+		Name: "unlink-duplicate-tpipe-pipelines",
+		Stmt: unlinkDuplicateTpipePipelines,
+		Down: revertUnlinkDuplicateTpipePipelines,
+	},
+	{
+		Name: "remove-unique-index-tpipe-pipeline-slug-ref-path",
+		Stmt: removeUniqueIndexTpipePipelineSlugRefPath,
+		Down: revertRemoveUniqueIndexTpipePipelineSlugRefPath,
+	},
+	{
+		Name: "create-unique-index-tpipe-pipeline-repo-ref-path",
+		Stmt: createUniqueIndexTpipePipelineRepoRefPath,
+		Down: dropUniqueIndexTpipePipelineRepoRefPath,
+	},
+	{
+		Name: "alter-table-revisions-add-column-repo-id",
+		Stmt: alterTableRevisionsAddColumnRepoId,
+		Down: alterTableRevisionsDropColumnRepoId,
+	},
+	{
+		Name: "backfill-tpipe-revision-repo-id",
+		Stmt: backfillTpipeRevisionRepoId,
+		Down: revertBackfillTpipeRevisionRepoId,
+	},
+	{
+		Name: "create-index-tpipe-revision-repo-ref",
+		Stmt: createIndexTpipeRevisionRepoRef,
+		Down: dropIndexTpipeRevisionRepoRef,
 	},
 }
 
 // Migrate performs the database migration. If the migration fails
 // and error is returned.
 func Migrate(db *sql.DB) error {
-	if err := createTable(db); err != nil {
-		return err
-	}
-	completed, err := selectCompleted(db)
-	if err != nil && err != sql.ErrNoRows {
-		return err
-	}
-	for _, migration := range migrations {
-		if _, ok := completed[migration.name]; ok {
-
-			continue
-		}
-
-		if _, err := db.Exec(migration.stmt); err != nil {
-			return err
-		}
-		if err := insertMigration(db, migration.name); err != nil {
-			return err
-		}
-
-	}
-	return nil
-}
-
-func createTable(db *sql.DB) error {
-	_, err := db.Exec(migrationTableCreate)
+	_, err := New(db).Up()
 	return err
 }
 
-func insertMigration(db *sql.DB, name string) error {
-	_, err := db.Exec(migrationInsert, name)
-	return err
+// New returns a migrator for the database.
+func New(db *sql.DB) *migrate.Migrator {
+	return migrate.New(db, dialect, migrations)
 }
 
-func selectCompleted(db *sql.DB) (map[string]struct{}, error) {
-	migrations := map[string]struct{}{}
-	rows, err := db.Query(migrationSelect)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		migrations[name] = struct{}{}
-	}
-	return migrations, nil
+var dialect = migrate.Dialect{
+	Exists:       tableExists,
+	Create:       migrationTableCreate,
+	Insert:       migrationInsert,
+	Delete:       migrationDelete,
+	Select:       migrationSelect,
+	LegacyCreate: legacyTableCreate,
+	LegacySelect: legacySelect,
+	LegacyDelete: legacyDelete,
 }
 
 //
 // migration table ddl and sql
 //
 
+var tableExists = `
+SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?
+`
+
 var migrationTableCreate = `
-CREATE TABLE IF NOT EXISTS migrations (
- name VARCHAR(255)
-,UNIQUE(name)
+CREATE TABLE IF NOT EXISTS tpipe_migrations (
+ migration_name     VARCHAR(255)
+,migration_checksum VARCHAR(64)
+,migration_applied  INTEGER
+,UNIQUE(migration_name)
 )
 `
 
 var migrationInsert = `
-INSERT INTO migrations (name) VALUES (?)
+INSERT INTO tpipe_migrations (migration_name, migration_checksum, migration_applied) VALUES (?, ?, ?)
+`
+
+var migrationDelete = `
+DELETE FROM tpipe_migrations WHERE migration_name = ?
 `
 
 var migrationSelect = `
+SELECT migration_name, migration_checksum, migration_applied FROM tpipe_migrations
+`
+
+//
+// legacy migration table shared with drone
+//
+
+var legacyTableCreate = `
+CREATE TABLE IF NOT EXISTS migrations (
+ name VARCHAR(255)
+,UNIQUE(name)
+)
+`
+
+var legacySelect = `
 SELECT name FROM migrations
 `
 
+var legacyDelete = `
+DELETE FROM migrations WHERE name = ?
+`
+
 //
 // 001_create_table_tpipe_template.sql
 //
@@ -109,6 +247,10 @@ CREATE TABLE IF NOT EXISTS tpipe_templates (
 );
 `
 
+var dropTableTpipeTemplate = `
+DROP TABLE IF EXISTS tpipe_templates;
+`
+
 //
 // 002_create_table_tpipe_pipeline.sql
 //
@@ -127,3 +269,448 @@ CREATE TABLE IF NOT EXISTS tpipe_pipelines (
 	UNIQUE ( pipeline_uuid ) 
 );
 `
+
+var alterTablePipelinesAddColumnParams = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_params TEXT;
+`
+
+var alterTablePipelinesAddColumnTemplate = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_template VARCHAR(40);
+`
+
+var alterTablePipelinesAddColumnApproval = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_approval TEXT;
+`
+
+var dropTableTpipePipeline = `
+DROP TABLE IF EXISTS tpipe_pipelines;
+`
+
+var alterTablePipelinesDropColumnParams = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+`
+
+var alterTablePipelinesDropColumnTemplate = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+`
+
+var alterTablePipelinesDropColumnApproval = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+`
+
+//
+// 003_create_table_tpipe_credential.sql
+//
+
+var createTableTpipeCredential = `
+CREATE TABLE IF NOT EXISTS tpipe_credentials (
+	credential_slug TEXT,
+	credential_type VARCHAR(50),
+	credential_username VARCHAR(255),
+	credential_password TEXT,
+	credential_key_id VARCHAR(50),
+	credential_public_key TEXT,
+	credential_created INTEGER,
+	credential_updated INTEGER,
+	UNIQUE ( credential_slug )
+);
+`
+
+var dropTableTpipeCredential = `
+DROP TABLE IF EXISTS tpipe_credentials;
+`
+
+//
+// 004_create_table_tpipe_override.sql
+//
+
+var createTableTpipeOverride = `
+CREATE TABLE IF NOT EXISTS tpipe_overrides (
+	override_uuid VARCHAR(40),
+	override_slug TEXT,
+	override_build_id INTEGER,
+	override_number INTEGER,
+	override_content TEXT,
+	override_creator VARCHAR(255),
+	override_created INTEGER,
+	UNIQUE ( override_uuid )
+);
+`
+
+var dropTableTpipeOverride = `
+DROP TABLE IF EXISTS tpipe_overrides;
+`
+
+//
+// 005_create_table_tpipe_approval.sql
+//
+
+var createTableTpipeApproval = `
+CREATE TABLE IF NOT EXISTS tpipe_approvals (
+	approval_build_id INTEGER,
+	approval_slug TEXT,
+	approval_number INTEGER,
+	approval_user VARCHAR(255),
+	approval_created INTEGER,
+	UNIQUE ( approval_build_id, approval_user )
+);
+`
+
+var dropTableTpipeApproval = `
+DROP TABLE IF EXISTS tpipe_approvals;
+`
+
+//
+// 006_create_table_tpipe_revision.sql
+//
+
+var createTableTpipeRevision = `
+CREATE TABLE IF NOT EXISTS tpipe_revisions (
+	revision_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	revision_slug TEXT,
+	revision_ref VARCHAR(255),
+	revision_content TEXT,
+	revision_created INTEGER
+);
+`
+
+var createIndexTpipeRevisionSlugRef = `
+CREATE INDEX ix_tpipe_revision_slug_ref ON tpipe_revisions (revision_slug, revision_ref);
+`
+
+var backfillTpipeRevisions = `
+INSERT INTO tpipe_revisions (revision_slug, revision_ref, revision_content, revision_created)
+SELECT pipeline_slug, pipeline_ref, pipeline_content, pipeline_created
+FROM tpipe_pipelines;
+`
+
+var dropTableTpipeRevision = `
+DROP TABLE IF EXISTS tpipe_revisions;
+`
+
+var dropIndexTpipeRevisionSlugRef = `
+DROP INDEX ix_tpipe_revision_slug_ref;
+`
+
+var deleteTpipeRevisions = `
+DELETE FROM tpipe_revisions;
+`
+
+//
+// 007_create_index_tpipe_pipeline.sql
+//
+
+var alterTablePipelinesAddColumnConfigPath = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_config_path TEXT DEFAULT '.drone.yml';
+`
+
+var dedupeTpipePipelines = `
+-- the most recently updated pipeline is kept. The duplicates
+-- are deleted, so the migration cannot be reverted.
+
+DELETE FROM tpipe_pipelines
+WHERE EXISTS (
+	SELECT 1 FROM tpipe_pipelines b
+	WHERE b.pipeline_slug = tpipe_pipelines.pipeline_slug
+	AND b.pipeline_ref = tpipe_pipelines.pipeline_ref
+	AND b.pipeline_config_path = tpipe_pipelines.pipeline_config_path
+	AND (COALESCE(b.pipeline_updated, 0) > COALESCE(tpipe_pipelines.pipeline_updated, 0) OR (COALESCE(b.pipeline_updated, 0) = COALESCE(tpipe_pipelines.pipeline_updated, 0) AND b.pipeline_uuid > tpipe_pipelines.pipeline_uuid))
+);
+`
+
+var createUniqueIndexTpipePipelineSlugRefPath = `
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+`
+
+var createIndexTpipePipelineSlug = `
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+`
+
+var alterTablePipelinesDropColumnConfigPath = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+`
+
+var dropUniqueIndexTpipePipelineSlugRefPath = `
+DROP INDEX ux_tpipe_pipeline_slug_ref_path;
+`
+
+var dropIndexTpipePipelineSlug = `
+DROP INDEX ix_tpipe_pipeline_slug;
+`
+
+//
+// 008_alter_table_tpipe_pipeline_repo.sql
+//
+
+var alterTablePipelinesAddColumnRepoId = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_id INTEGER DEFAULT 0;
+`
+
+var alterTablePipelinesAddColumnRepoUid = `
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_uid TEXT DEFAULT '';
+`
+
+var createIndexTpipePipelineRepoId = `
+CREATE INDEX ix_tpipe_pipeline_repo_id ON tpipe_pipelines (pipeline_repo_id);
+`
+
+var backfillTpipePipelineRepoId = `
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = COALESCE((SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), 0)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '');
+`
+
+var alterTablePipelinesDropColumnRepoId = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	pipeline_config_path TEXT DEFAULT '.drone.yml',
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval, pipeline_config_path FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+`
+
+var alterTablePipelinesDropColumnRepoUid = `
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	pipeline_config_path TEXT DEFAULT '.drone.yml',
+	pipeline_repo_id INTEGER DEFAULT 0,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval, pipeline_config_path, pipeline_repo_id FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+`
+
+var dropIndexTpipePipelineRepoId = `
+DROP INDEX ix_tpipe_pipeline_repo_id;
+`
+
+var revertBackfillTpipePipelineRepoId = `
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0, pipeline_repo_uid = '';
+`
+
+//
+// 010_create_table_tpipe_audit.sql
+//
+
+var createTableTpipeAudit = `
+CREATE TABLE IF NOT EXISTS tpipe_audits (
+	audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	audit_actor VARCHAR(250),
+	audit_action VARCHAR(250),
+	audit_target TEXT,
+	audit_before VARCHAR(100),
+	audit_after VARCHAR(100),
+	audit_source_ip VARCHAR(100),
+	audit_status INTEGER,
+	audit_created INTEGER
+);
+`
+
+var createIndexTpipeAuditCreated = `
+CREATE INDEX ix_tpipe_audit_created ON tpipe_audits (audit_created);
+`
+
+var createIndexTpipeAuditActor = `
+CREATE INDEX ix_tpipe_audit_actor ON tpipe_audits (audit_actor);
+`
+
+var dropTableTpipeAudit = `
+DROP TABLE IF EXISTS tpipe_audits;
+`
+
+var dropIndexTpipeAuditCreated = `
+DROP INDEX ix_tpipe_audit_created;
+`
+
+var dropIndexTpipeAuditActor = `
+DROP INDEX ix_tpipe_audit_actor;
+`
+
+//
+// 011_create_index_tpipe_override.sql
+//
+
+var createIndexTpipeOverrideBuildId = `
+CREATE INDEX ix_tpipe_override_build_id ON tpipe_overrides (override_build_id);
+`
+
+var dropIndexTpipeOverrideBuildId = `
+DROP INDEX ix_tpipe_override_build_id;
+`
+
+//
+// 012_alter_table_tpipe_pipeline_repo_key.sql
+//
+
+var backfillTpipePipelineRepoLink = `
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = (SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '')
+WHERE pipeline_repo_id IS NULL OR pipeline_repo_id = 0;
+`
+
+var unlinkDuplicateTpipePipelines = `
+UPDATE tpipe_pipelines SET pipeline_repo_id = NULL
+WHERE EXISTS (
+	SELECT 1 FROM tpipe_pipelines b
+	WHERE b.pipeline_repo_id = tpipe_pipelines.pipeline_repo_id
+	AND b.pipeline_ref = tpipe_pipelines.pipeline_ref
+	AND b.pipeline_config_path = tpipe_pipelines.pipeline_config_path
+	AND (COALESCE(b.pipeline_updated, 0) > COALESCE(tpipe_pipelines.pipeline_updated, 0) OR (COALESCE(b.pipeline_updated, 0) = COALESCE(tpipe_pipelines.pipeline_updated, 0) AND b.pipeline_uuid > tpipe_pipelines.pipeline_uuid))
+);
+`
+
+var removeUniqueIndexTpipePipelineSlugRefPath = `
+DROP INDEX ux_tpipe_pipeline_slug_ref_path;
+`
+
+var createUniqueIndexTpipePipelineRepoRefPath = `
+CREATE UNIQUE INDEX ux_tpipe_pipeline_repo_ref_path ON tpipe_pipelines (pipeline_repo_id, pipeline_ref, pipeline_config_path);
+`
+
+var alterTableRevisionsAddColumnRepoId = `
+ALTER TABLE tpipe_revisions ADD COLUMN revision_repo_id INTEGER DEFAULT 0;
+`
+
+var backfillTpipeRevisionRepoId = `
+UPDATE tpipe_revisions SET revision_repo_id = COALESCE(
+	(SELECT MAX(pipeline_repo_id) FROM tpipe_pipelines WHERE tpipe_pipelines.pipeline_slug = tpipe_revisions.revision_slug),
+	(SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_revisions.revision_slug),
+	0
+);
+`
+
+var createIndexTpipeRevisionRepoRef = `
+CREATE INDEX ix_tpipe_revision_repo_ref ON tpipe_revisions (revision_repo_id, revision_ref);
+`
+
+var revertBackfillTpipePipelineRepoLink = `
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0 WHERE pipeline_repo_id IS NULL;
+`
+
+var revertUnlinkDuplicateTpipePipelines = `
+-- the older duplicates stay unlinked. They are kept, and are
+-- reset to pipelines without a repository when the links are
+-- reverted.
+`
+
+var revertRemoveUniqueIndexTpipePipelineSlugRefPath = `
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+`
+
+var dropUniqueIndexTpipePipelineRepoRefPath = `
+DROP INDEX ux_tpipe_pipeline_repo_ref_path;
+`
+
+var alterTableRevisionsDropColumnRepoId = `
+CREATE TABLE tpipe_revisions_down (
+	revision_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	revision_slug TEXT,
+	revision_ref VARCHAR(255),
+	revision_content TEXT,
+	revision_created INTEGER
+);
+INSERT INTO tpipe_revisions_down SELECT revision_id, revision_slug, revision_ref, revision_content, revision_created FROM tpipe_revisions;
+DROP TABLE tpipe_revisions;
+ALTER TABLE tpipe_revisions_down RENAME TO tpipe_revisions;
+CREATE INDEX ix_tpipe_revision_slug_ref ON tpipe_revisions (revision_slug, revision_ref);
+`
+
+var revertBackfillTpipeRevisionRepoId = `
+UPDATE tpipe_revisions SET revision_repo_id = 0;
+`
+
+var dropIndexTpipeRevisionRepoRef = `
+DROP INDEX ix_tpipe_revision_repo_ref;
+`
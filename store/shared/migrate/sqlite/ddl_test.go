@@ -0,0 +1,256 @@
+package sqlite
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oars-sigs/drone/store/shared/migrate"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMigrateUpDown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the backfills read the drone tables.
+	if _, err := db.Exec("CREATE TABLE repos (repo_id INTEGER, repo_uid TEXT, repo_slug TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// migrations recorded by earlier versions are imported.
+	if _, err := db.Exec(legacyTableCreate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(createTableTpipeTemplate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO migrations (name) VALUES (?), (?)", "create-table-tpipe-template", "create-table-repos"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(db)
+	n, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, len(migrations)-1; got != want {
+		t.Errorf("Want %d migrations applied, got %d", want, got)
+	}
+	if err := m.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	var legacy int
+	db.QueryRow("SELECT count(*) FROM migrations").Scan(&legacy)
+	if legacy != 1 {
+		t.Errorf("Want drone migrations kept in the legacy table, got %d rows", legacy)
+	}
+
+	_, err = db.Exec("INSERT INTO tpipe_pipelines (pipeline_uuid, pipeline_slug, pipeline_params) VALUES ('1', 'octocat/hello-world', '[]')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the migrations after the dedupe of the pipelines can be
+	// reverted, the dedupe itself cannot.
+	reversible := 0
+	for i := len(migrations) - 1; migrations[i].Name != "dedupe-tpipe-pipelines"; i-- {
+		reversible++
+	}
+	n, err = m.Down(len(migrations))
+	if err == nil || !strings.Contains(err.Error(), migrate.ErrIrreversible.Error()) {
+		t.Errorf("Want the dedupe of the pipelines irreversible, got %v", err)
+	}
+	if got, want := n, reversible; got != want {
+		t.Errorf("Want %d migrations reverted, got %d", want, got)
+	}
+	var slug string
+	if err := db.QueryRow("SELECT pipeline_slug FROM tpipe_pipelines").Scan(&slug); err != nil {
+		t.Errorf("Want pipelines kept after reverting added columns, got %s", err)
+	}
+
+	n, err = m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, reversible; got != want {
+		t.Errorf("Want %d migrations applied, got %d", want, got)
+	}
+
+	list, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range list {
+		if status.Pending || status.Modified || status.Unknown {
+			t.Errorf("Want migration %s applied, got %+v", status.Name, status)
+		}
+	}
+}
+
+func TestMigrateStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(legacyTableCreate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO migrations (name) VALUES (?)", "create-table-tpipe-template"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(db)
+	list, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(list), len(migrations); got != want {
+		t.Fatalf("Want %d migrations, got %d", want, got)
+	}
+	if status := list[0]; status.Pending || status.Applied != 0 {
+		t.Errorf("Want the legacy migration applied, got %+v", status)
+	}
+	for _, status := range list[1:] {
+		if !status.Pending {
+			t.Errorf("Want migration %s pending, got %+v", status.Name, status)
+		}
+	}
+	if err := m.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	// the status is read only, the legacy migrations are
+	// imported by up.
+	var count int
+	db.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = 'tpipe_migrations'").Scan(&count)
+	if count != 0 {
+		t.Errorf("Want the migration table not created")
+	}
+	db.QueryRow("SELECT count(*) FROM migrations").Scan(&count)
+	if count != 1 {
+		t.Errorf("Want the legacy migration kept, got %d rows", count)
+	}
+
+	_, err = m.Down(len(migrations))
+	if err == nil || !strings.Contains(err.Error(), migrate.ErrLegacy.Error()) {
+		t.Errorf("Want the legacy migration not reverted before up, got %v", err)
+	}
+}
+
+func TestMigrateVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE repos (repo_id INTEGER, repo_uid TEXT, repo_slug TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	m := New(db)
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("UPDATE tpipe_migrations SET migration_checksum = 'x' WHERE migration_name = ?", "create-table-tpipe-template")
+	db.Exec(migrationInsert, "create-table-tpipe-future", "x", 1)
+
+	if err := m.Verify(); err == nil {
+		t.Errorf("Want modified and unknown migrations reported")
+	}
+	if _, err := m.Down(1); err == nil {
+		t.Errorf("Want down refused with unknown migrations")
+	}
+}
+
+func TestMigrateRepoKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE repos (repo_id INTEGER, repo_uid TEXT, repo_slug TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO repos VALUES (1, '1', 'octocat/hello-world')"); err != nil {
+		t.Fatal(err)
+	}
+	m := New(db)
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	// the pipelines are stored before the repository id is the
+	// unique key.
+	if _, err := m.Down(7); err != nil {
+		t.Fatal(err)
+	}
+	stmts := []string{
+		"INSERT INTO tpipe_pipelines (pipeline_uuid, pipeline_slug, pipeline_ref, pipeline_updated, pipeline_repo_id) VALUES ('a', 'octocat/hello-world', 'default', 1, 0)",
+		"INSERT INTO tpipe_pipelines (pipeline_uuid, pipeline_slug, pipeline_ref, pipeline_updated, pipeline_repo_id) VALUES ('b', 'octocat/hello-world-old', 'default', 2, 1)",
+		"INSERT INTO tpipe_pipelines (pipeline_uuid, pipeline_slug, pipeline_ref, pipeline_updated, pipeline_repo_id) VALUES ('c', 'octocat/spoon-knife', 'default', 1, 0)",
+		"INSERT INTO tpipe_pipelines (pipeline_uuid, pipeline_slug, pipeline_ref, pipeline_updated, pipeline_repo_id) VALUES ('d', 'octocat/linguist', 'default', 1, 0)",
+		"INSERT INTO tpipe_revisions (revision_slug, revision_ref, revision_created) VALUES ('octocat/hello-world-old', 'default', 2)",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]sql.NullInt64{
+		"a": {},
+		"b": {Int64: 1, Valid: true},
+		"c": {},
+		"d": {},
+	}
+	for uuid, repoID := range want {
+		var got sql.NullInt64
+		db.QueryRow("SELECT pipeline_repo_id FROM tpipe_pipelines WHERE pipeline_uuid = ?", uuid).Scan(&got)
+		if got != repoID {
+			t.Errorf("Want pipeline %s linked to %v, got %v", uuid, repoID, got)
+		}
+	}
+	var repoID int64
+	db.QueryRow("SELECT revision_repo_id FROM tpipe_revisions").Scan(&repoID)
+	if repoID != 1 {
+		t.Errorf("Want revisions linked to the repository of the pipeline, got %d", repoID)
+	}
+}
@@ -11,4 +11,79 @@ CREATE TABLE IF NOT EXISTS tpipe_pipelines (
 	pipeline_created INTEGER,
 	pipeline_updated INTEGER,
 	UNIQUE ( pipeline_uuid ) 
-);
\ No newline at end of file
+);
+
+-- name: alter-table-pipelines-add-column-params
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_params TEXT;
+
+
+-- name: alter-table-pipelines-add-column-template
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_template VARCHAR(40);
+
+
+-- name: alter-table-pipelines-add-column-approval
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_approval TEXT;
+
+-- name: drop-table-tpipe-pipeline
+
+DROP TABLE IF EXISTS tpipe_pipelines;
+
+-- name: alter-table-pipelines-drop-column-params
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+
+-- name: alter-table-pipelines-drop-column-template
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+
+-- name: alter-table-pipelines-drop-column-approval
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
@@ -0,0 +1,17 @@
+-- name: create-table-tpipe-credential
+
+CREATE TABLE IF NOT EXISTS tpipe_credentials (
+	credential_slug TEXT,
+	credential_type VARCHAR(50),
+	credential_username VARCHAR(255),
+	credential_password TEXT,
+	credential_key_id VARCHAR(50),
+	credential_public_key TEXT,
+	credential_created INTEGER,
+	credential_updated INTEGER,
+	UNIQUE ( credential_slug )
+);
+
+-- name: drop-table-tpipe-credential
+
+DROP TABLE IF EXISTS tpipe_credentials;
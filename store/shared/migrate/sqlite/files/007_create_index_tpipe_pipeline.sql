@@ -0,0 +1,54 @@
+-- name: alter-table-pipelines-add-column-config-path
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_config_path TEXT DEFAULT '.drone.yml';
+
+-- name: dedupe-tpipe-pipelines
+
+-- the most recently updated pipeline is kept. The duplicates
+-- are deleted, so the migration cannot be reverted.
+
+DELETE FROM tpipe_pipelines
+WHERE EXISTS (
+	SELECT 1 FROM tpipe_pipelines b
+	WHERE b.pipeline_slug = tpipe_pipelines.pipeline_slug
+	AND b.pipeline_ref = tpipe_pipelines.pipeline_ref
+	AND b.pipeline_config_path = tpipe_pipelines.pipeline_config_path
+	AND (COALESCE(b.pipeline_updated, 0) > COALESCE(tpipe_pipelines.pipeline_updated, 0) OR (COALESCE(b.pipeline_updated, 0) = COALESCE(tpipe_pipelines.pipeline_updated, 0) AND b.pipeline_uuid > tpipe_pipelines.pipeline_uuid))
+);
+
+-- name: create-unique-index-tpipe-pipeline-slug-ref-path
+
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+
+-- name: create-index-tpipe-pipeline-slug
+
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+
+-- name: alter-table-pipelines-drop-column-config-path
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+
+-- name: drop-unique-index-tpipe-pipeline-slug-ref-path
+
+DROP INDEX ux_tpipe_pipeline_slug_ref_path;
+
+-- name: drop-index-tpipe-pipeline-slug
+
+DROP INDEX ix_tpipe_pipeline_slug;
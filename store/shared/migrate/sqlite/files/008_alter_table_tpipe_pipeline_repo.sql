@@ -0,0 +1,74 @@
+-- name: alter-table-pipelines-add-column-repo-id
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_id INTEGER DEFAULT 0;
+
+-- name: alter-table-pipelines-add-column-repo-uid
+
+ALTER TABLE tpipe_pipelines ADD COLUMN pipeline_repo_uid TEXT DEFAULT '';
+
+-- name: create-index-tpipe-pipeline-repo-id
+
+CREATE INDEX ix_tpipe_pipeline_repo_id ON tpipe_pipelines (pipeline_repo_id);
+
+-- name: backfill-tpipe-pipeline-repo-id
+
+UPDATE tpipe_pipelines SET
+ pipeline_repo_id = COALESCE((SELECT repo_id FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), 0)
+,pipeline_repo_uid = COALESCE((SELECT repo_uid FROM repos WHERE repos.repo_slug = tpipe_pipelines.pipeline_slug), '');
+
+-- name: alter-table-pipelines-drop-column-repo-id
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	pipeline_config_path TEXT DEFAULT '.drone.yml',
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval, pipeline_config_path FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+
+-- name: alter-table-pipelines-drop-column-repo-uid
+
+CREATE TABLE tpipe_pipelines_down (
+	pipeline_uuid TEXT,
+	pipeline_name TEXT,
+	pipeline_repo TEXT,
+	pipeline_slug TEXT,
+	pipeline_ref TEXT,
+	pipeline_sync INT(2) DEFAULT 0,
+	pipeline_content TEXT,
+	pipeline_created INTEGER,
+	pipeline_updated INTEGER,
+	pipeline_params TEXT,
+	pipeline_template VARCHAR(40),
+	pipeline_approval TEXT,
+	pipeline_config_path TEXT DEFAULT '.drone.yml',
+	pipeline_repo_id INTEGER DEFAULT 0,
+	UNIQUE ( pipeline_uuid )
+);
+INSERT INTO tpipe_pipelines_down SELECT pipeline_uuid, pipeline_name, pipeline_repo, pipeline_slug, pipeline_ref, pipeline_sync, pipeline_content, pipeline_created, pipeline_updated, pipeline_params, pipeline_template, pipeline_approval, pipeline_config_path, pipeline_repo_id FROM tpipe_pipelines;
+DROP TABLE tpipe_pipelines;
+ALTER TABLE tpipe_pipelines_down RENAME TO tpipe_pipelines;
+CREATE UNIQUE INDEX ux_tpipe_pipeline_slug_ref_path ON tpipe_pipelines (pipeline_slug, pipeline_ref, pipeline_config_path);
+CREATE INDEX ix_tpipe_pipeline_slug ON tpipe_pipelines (pipeline_slug);
+
+-- name: drop-index-tpipe-pipeline-repo-id
+
+DROP INDEX ix_tpipe_pipeline_repo_id;
+
+-- name: revert-backfill-tpipe-pipeline-repo-id
+
+UPDATE tpipe_pipelines SET pipeline_repo_id = 0, pipeline_repo_uid = '';
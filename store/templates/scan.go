@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
 
 	"github.com/drone/drone/store/shared/db"
 	"github.com/google/uuid"
@@ -12,7 +13,11 @@ import (
 
 // helper function converts the Plugin structure to a set
 // of named query parameters.
-func toParams(t *model.Template) map[string]interface{} {
+func toParams(enc encrypt.Encrypter, t *model.Template) (map[string]interface{}, error) {
+	content, err := encrypt.EncryptText(enc, t.Content)
+	if err != nil {
+		return nil, err
+	}
 	uuid := uuid.New().String()
 	created_time := time.Now().Unix()
 	updated_time := time.Now().Unix()
@@ -21,27 +26,31 @@ func toParams(t *model.Template) map[string]interface{} {
 		"template_name":    t.Name,
 		"template_format":  t.Format,
 		"template_type":    t.Type,
-		"template_content": t.Content,
+		"template_content": content,
 		"template_updated": updated_time,
 		"template_created": created_time,
-	}
+	}, nil
 }
 
-func toParam(t *model.Template) map[string]interface{} {
+func toParam(enc encrypt.Encrypter, t *model.Template) (map[string]interface{}, error) {
+	content, err := encrypt.EncryptText(enc, t.Content)
+	if err != nil {
+		return nil, err
+	}
 	updated_time := time.Now().Unix()
 	return map[string]interface{}{
 		"template_uuid":    t.UUID,
 		"template_name":    t.Name,
 		"template_format":  t.Format,
 		"template_type":    t.Type,
-		"template_content": t.Content,
+		"template_content": content,
 		"template_updated": updated_time,
-	}
+	}, nil
 }
 
 // helper function scans the sql.Row and copies the column
 // values to the destination object.
-func scanRow(scanner db.Scanner, dest *model.Template) error {
+func scanRow(enc encrypt.Encrypter, scanner db.Scanner, dest *model.Template) error {
 	err := scanner.Scan(
 		&dest.UUID,
 		&dest.Name,
@@ -51,18 +60,22 @@ func scanRow(scanner db.Scanner, dest *model.Template) error {
 		&dest.Updated,
 		&dest.Created,
 	)
+	if err != nil {
+		return err
+	}
+	dest.Content, err = encrypt.DecryptText(enc, dest.Content)
 	return err
 }
 
 // helper function scans the sql.Row and copies the column
 // values to the destination object.
-func scanRows(rows *sql.Rows) ([]*model.Template, error) {
+func scanRows(enc encrypt.Encrypter, rows *sql.Rows) ([]*model.Template, error) {
 	defer rows.Close()
 
 	templates := []*model.Template{}
 	for rows.Next() {
 		template := new(model.Template)
-		err := scanRow(rows, template)
+		err := scanRow(enc, rows, template)
 		if err != nil {
 			return nil, err
 		}
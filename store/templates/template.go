@@ -7,17 +7,22 @@ import (
 
 	"github.com/drone/drone/store/shared/db"
 	"github.com/oars-sigs/drone/model"
+	"github.com/oars-sigs/drone/store/shared/encrypt"
 	"github.com/sirupsen/logrus"
 )
 
-func New(db *db.DB) model.TemplateStore {
+// New returns a new TemplateStore. The template content is
+// encrypted if the encrypter has a key.
+func New(db *db.DB, enc encrypt.Encrypter) model.TemplateStore {
 	return &tpmlStore{
-		db: db,
+		db:  db,
+		enc: enc,
 	}
 }
 
 type tpmlStore struct {
-	db *db.DB
+	db  *db.DB
+	enc encrypt.Encrypter
 }
 
 //Get returns a list of templates from the datastore.
@@ -28,7 +33,7 @@ func (s *tpmlStore) GetTemplate() ([]*model.Template, error) {
 		if err != nil {
 			return err
 		}
-		out, err = scanRows(rows)
+		out, err = scanRows(s.enc, rows)
 		return err
 	})
 	return out, err
@@ -44,7 +49,10 @@ func (s *tpmlStore) CreateTemplate(ctx context.Context, tmpl *model.Template) er
 		return errors.New("template name is exist")
 	}
 	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
-		params := toParams(tmpl)
+		params, err := toParams(s.enc, tmpl)
+		if err != nil {
+			return err
+		}
 		stmt, args, err := binder.BindNamed(stmtInsert, params)
 		if err != nil {
 			return err
@@ -67,7 +75,10 @@ func (s *tpmlStore) PutTemplate(ctx context.Context, tmpl *model.Template) error
 		return errors.New("template name is exist")
 	}
 	return s.db.Lock(func(execer db.Execer, binder db.Binder) error {
-		params := toParam(tmpl)
+		params, err := toParam(s.enc, tmpl)
+		if err != nil {
+			return err
+		}
 		stmt, args, err := binder.BindNamed(stmtUpdate, params)
 		if err != nil {
 			return err
@@ -86,7 +97,10 @@ func (s *tpmlStore) DeleteTemplate(ctx context.Context, uuid string) error {
 		tmp := &model.Template{
 			UUID: uuid,
 		}
-		params := toParam(tmp)
+		params, err := toParam(s.enc, tmp)
+		if err != nil {
+			return err
+		}
 		stmt, args, err := binder.BindNamed(stmtDelete, params)
 		if err != nil {
 			return err
@@ -105,7 +119,10 @@ func (s *tpmlStore) FindTemplate(ctx context.Context, uuid string) (*model.Templ
 		UUID: uuid,
 	}
 	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
-		params := toParam(out)
+		params, err := toParam(s.enc, out)
+		if err != nil {
+			return err
+		}
 		query, args, err := binder.BindNamed(queryByUuid, params)
 		if err != nil {
 			return err
@@ -115,7 +132,7 @@ func (s *tpmlStore) FindTemplate(ctx context.Context, uuid string) (*model.Templ
 			return err
 		}
 
-		err = scanRow(row, out)
+		err = scanRow(s.enc, row, out)
 		return err
 	})
 	logrus.Debug(out)
@@ -128,7 +145,10 @@ func (s *tpmlStore) FindTemplateName(ctx context.Context, name string) (*model.T
 		Name: name,
 	}
 	err := s.db.View(func(queryer db.Queryer, binder db.Binder) error {
-		params := toParam(out)
+		params, err := toParam(s.enc, out)
+		if err != nil {
+			return err
+		}
 		query, args, err := binder.BindNamed(queryByName, params)
 		if err != nil {
 			return err
@@ -138,7 +158,7 @@ func (s *tpmlStore) FindTemplateName(ctx context.Context, name string) (*model.T
 			return err
 		}
 
-		err = scanRow(row, out)
+		err = scanRow(s.enc, row, out)
 		return err
 	})
 	if err != nil {